@@ -0,0 +1,201 @@
+package roboeyestinygo
+
+import "image/color"
+
+// Sub-pixel sample offsets in 1/8 pixel units, 4x4 samples per pixel
+var aaSampleOffsets = [4]int32{-3, -1, 1, 3}
+
+// SetAntialiasing enables/disables anti-aliased rounded corners and eyelid
+// edges. Edge pixels are blended between eyesColor and bgColor, which looks
+// smoother on color and grayscale panels; keep it off for monochrome OLEDs.
+func (r *RoboEyes) SetAntialiasing(active bool) {
+	r.antialias = active
+}
+
+// fillRoundRectAA is fillRoundRect with per-pixel coverage on the corners
+func (r *RoboEyes) fillRoundRectAA(x, y, width, height, radius int16, c color.RGBA) {
+	maxRadius := min(radius, width/2, height/2)
+	if height <= 2 || width <= 2 || maxRadius < 1 {
+		r.fillRoundRect(x, y, width, height, radius, c)
+		return
+	}
+
+	// Straight parts are identical to the aliased shape
+	r.fillRect(x+maxRadius, y, width-2*maxRadius, height, c)
+	r.fillRect(x, y+maxRadius, maxRadius, height-2*maxRadius, c)
+	r.fillRect(x+width-maxRadius, y+maxRadius, maxRadius, height-2*maxRadius, c)
+
+	// Corner squares, each with the center of its quarter circle
+	left, right := x, x+width-maxRadius
+	top, bottom := y, y+height-maxRadius
+	r.fillCornerAA(left, top, x+maxRadius, y+maxRadius, maxRadius, c)
+	r.fillCornerAA(right, top, x+width-maxRadius-1, y+maxRadius, maxRadius, c)
+	r.fillCornerAA(left, bottom, x+maxRadius, y+height-maxRadius-1, maxRadius, c)
+	r.fillCornerAA(right, bottom, x+width-maxRadius-1, y+height-maxRadius-1, maxRadius, c)
+}
+
+// fillCornerAA paints the radius x radius square at (x, y) with the coverage of
+// the circle of the given radius centered on pixel (cx, cy)
+func (r *RoboEyes) fillCornerAA(x, y, cx, cy, radius int16, c color.RGBA) {
+	under := r.underColor(c)
+	if r.shading != nil {
+		under = r.shading.under
+	}
+	// Eyes without a halo are blended with the background picture below them
+	overBackground := r.background != nil && c == r.eyesColor &&
+		(r.shading == nil || r.shading.style.Halo == 0)
+	limit := int32(radius)*8 + 4 // Circle edge runs half a pixel outside the center pixels
+	limit *= limit
+	for py := y; py < y+radius; py++ {
+		for px := x; px < x+radius; px++ {
+			dx, dy := int32(px-cx)*8, int32(py-cy)*8
+			covered := 0
+			for _, oy := range aaSampleOffsets {
+				sy := (dy + oy) * (dy + oy)
+				for _, ox := range aaSampleOffsets {
+					if (dx+ox)*(dx+ox)+sy <= limit {
+						covered++
+					}
+				}
+			}
+			if covered == 0 {
+				continue
+			}
+			pc := c
+			if r.shading != nil {
+				pc = r.shading.shade(px, py)
+			}
+			if covered == 16 {
+				r.setPixel(px, py, pc)
+				continue
+			}
+			if overBackground {
+				under = r.background.ColorAt(px, py)
+			}
+			r.setPixel(px, py, blendColor(under, pc, uint8(covered*255/16)))
+		}
+	}
+}
+
+// fillTriangleAA is fillTriangle with blended pixels along sloped edges,
+// weighted by how much of each pixel the exact edge covers
+func (r *RoboEyes) fillTriangleAA(x0, y0, x1, y1, x2, y2 int16, c color.RGBA) {
+	x0, y0, x1, y1, x2, y2 = sortTriangle(x0, y0, x1, y1, x2, y2)
+	totalHeight := y2 - y0
+	if totalHeight == 0 {
+		return // Degenerate triangle (zero area)
+	}
+
+	under := r.underColor(c)
+	long := newEdgeWalker(x0, x2-x0, totalHeight)
+	short := newEdgeWalker(x0, x1-x0, max(y1-y0, 1))
+	last := y2
+	if y1 == y2 {
+		last-- // Flat bottom edge is not drawn, as in fillTriangle
+	}
+	for y := y0; y <= last; y++ {
+		if y == y1 {
+			// Switch from the top edge to the bottom edge
+			short = newEdgeWalker(x1, x2-x1, y2-y1)
+		}
+
+		lo, hi := &long, &short
+		if lo.x > hi.x {
+			lo, hi = hi, lo
+		}
+		// The exact edge lies err/dy pixels past x in the walking direction:
+		// outside the span it partially covers the next pixel, inside it
+		// partially uncovers the span end
+		x0, x1 := lo.x, hi.x
+		if lo.err > 0 {
+			if lo.sign < 0 {
+				r.blendPixel(x0-1, y, under, c, lo.coverage())
+			} else {
+				r.blendPixel(x0, y, under, c, 255-lo.coverage())
+				x0++
+			}
+		}
+		if hi.err > 0 {
+			if hi.sign > 0 {
+				r.blendPixel(x1+1, y, under, c, hi.coverage())
+			} else {
+				r.blendPixel(x1, y, under, c, 255-hi.coverage())
+				x1--
+			}
+		}
+		r.fillSpan(x0, x1, y, c)
+
+		long.next()
+		short.next()
+	}
+}
+
+// blendPixel paints a partially covered pixel. Eyelids are only blended over
+// pixels of the eyes, elsewhere the background is already in place, and show
+// the background picture when there is one.
+func (r *RoboEyes) blendPixel(x, y int16, under, c color.RGBA, alpha uint8) {
+	if c != r.eyesColor {
+		eye := r.eyeAt(x, y)
+		if eye == nil {
+			return
+		}
+		under = eye.shade(x, y)
+		if r.backdrop {
+			c = r.background.ColorAt(x, y)
+		}
+	}
+	r.setPixel(x, y, blendColor(under, c, alpha))
+}
+
+// eyeAt returns the shader of the eye whose shape contains the center of
+// pixel (x, y), nil outside the eyes
+func (r *RoboEyes) eyeAt(x, y int16) *eyeShader {
+	eyes := r.visibleShaders()
+	for i := range eyes {
+		s := &eyes[i]
+		if insideRoundRect(x, y, s.x, s.y, s.width, s.height, s.radius) {
+			return s
+		}
+	}
+	return nil
+}
+
+// insideRoundRect reports whether the center of pixel (px, py) lies in the
+// rounded rectangle drawn by fillRoundRectAA
+func insideRoundRect(px, py, x, y, width, height, radius int16) bool {
+	if px < x || px >= x+width || py < y || py >= y+height {
+		return false
+	}
+	radius = min(radius, width/2, height/2)
+	if radius < 1 {
+		return true
+	}
+	// Distance from the nearest corner center, zero along the straight parts
+	cx := max(x+radius-px, px-(x+width-radius-1), 0)
+	cy := max(y+radius-py, py-(y+height-radius-1), 0)
+	limit := int32(radius)*8 + 4
+	return int32(cx)*int32(cx)*64+int32(cy)*int32(cy)*64 <= limit*limit
+}
+
+// coverage returns how much of the next pixel the exact edge covers, 0-255
+func (e *edgeWalker) coverage() uint8 {
+	return uint8(int32(e.err) * 255 / int32(e.dy))
+}
+
+// underColor returns the color a shape painted with c is drawn over:
+// eyes are drawn over the background and eyelids over the eyes
+func (r *RoboEyes) underColor(c color.RGBA) color.RGBA {
+	if c == r.eyesColor {
+		return r.bgColor
+	}
+	return r.eyesColor
+}
+
+// blendColor mixes from and to, alpha 0 returns from and 255 returns to
+func blendColor(from, to color.RGBA, alpha uint8) color.RGBA {
+	a := int32(alpha)
+	mix := func(f, t uint8) uint8 {
+		return uint8(int32(f) + (int32(t)-int32(f))*a/255)
+	}
+	return color.RGBA{mix(from.R, to.R), mix(from.G, to.G), mix(from.B, to.B), mix(from.A, to.A)}
+}
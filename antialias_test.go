@@ -0,0 +1,57 @@
+package roboeyestinygo
+
+import (
+	"image/color"
+	"testing"
+)
+
+// renderMood draws a settled frame of the given mood
+func renderMood(d DeviceInterface, mood Mood, setup func(r *RoboEyes)) *RoboEyes {
+	r := newTestEyes(d, 50)
+	setup(r)
+	r.Open()
+	r.SetMood(mood)
+	step(r, 40)
+	return r
+}
+
+func TestAntialiasingBlendsEdges(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	for _, mood := range []Mood{MoodDefault, MoodTired, MoodAngry, MoodHappy} {
+		plain, smooth := newTestDevice(128, 64), newTestDevice(128, 64)
+		renderMood(plain, mood, func(r *RoboEyes) { r.SetColors(white, black) })
+		renderMood(smooth, mood, func(r *RoboEyes) { r.SetColors(white, black); r.SetAntialiasing(true) })
+
+		blended, moved := 0, 0
+		for i, c := range smooth.pix {
+			if c != white && c != black && c != (color.RGBA{}) {
+				blended++
+				// Only edge pixels of the aliased shapes are blended
+				if p := plain.pix[i]; p != white && p != black && p != (color.RGBA{}) {
+					t.Fatalf("%d: aliased pixel %v", mood, p)
+				}
+			} else if c != plain.pix[i] && (c == white) != (plain.pix[i] == white) {
+				moved++
+			}
+		}
+		if blended == 0 {
+			t.Errorf("mood %d: no blended pixel", mood)
+		}
+		if moved > blended {
+			t.Errorf("mood %d: %d solid pixels differ from the aliased frame, %d blended", mood, moved, blended)
+		}
+	}
+}
+
+func TestBlendColor(t *testing.T) {
+	from, to := color.RGBA{0, 100, 255, 255}, color.RGBA{255, 0, 55, 255}
+	if c := blendColor(from, to, 0); c != from {
+		t.Errorf("alpha 0: %v", c)
+	}
+	if c := blendColor(from, to, 255); c != to {
+		t.Errorf("alpha 255: %v", c)
+	}
+	if c := blendColor(from, to, 128); c != (color.RGBA{128, 50, 155, 255}) {
+		t.Errorf("alpha 128: %v", c)
+	}
+}
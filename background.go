@@ -0,0 +1,102 @@
+package roboeyestinygo
+
+import "image/color"
+
+// Background is the picture the eyes are drawn on, in place of the flat
+// background color. It is painted over the viewport when the buffer is
+// cleared and the eyelids are cut out of the eyes with it, so the picture
+// shows through them. ColorAt must always return the same color for a pixel:
+// unchanged frames are not redrawn and only the changed area is displayed.
+type Background interface {
+	ColorAt(x, y int16) color.RGBA
+}
+
+// SolidBackground fills the screen with a single color
+type SolidBackground color.RGBA
+
+// ColorAt returns the color of the background
+func (b SolidBackground) ColorAt(x, y int16) color.RGBA {
+	return color.RGBA(b)
+}
+
+// BitmapBackground is an image of Width x Height pixels stored row by row,
+// repeated to fill the screen when smaller
+type BitmapBackground struct {
+	Width, Height int16
+	Pixels        []color.RGBA
+}
+
+// ColorAt returns the image pixel shown at (x, y)
+func (b *BitmapBackground) ColorAt(x, y int16) color.RGBA {
+	if b.Width <= 0 || b.Height <= 0 {
+		return color.RGBA{}
+	}
+	i := int(y%b.Height)*int(b.Width) + int(x%b.Width)
+	if i >= len(b.Pixels) {
+		return color.RGBA{}
+	}
+	return b.Pixels[i]
+}
+
+// ScanlinesBackground draws horizontal lines of LineColor, Thickness pixels
+// high every Spacing rows, over Color
+type ScanlinesBackground struct {
+	Color, LineColor   color.RGBA
+	Spacing, Thickness int16
+}
+
+// ColorAt returns the line color on the scanlines and Color between them
+func (b *ScanlinesBackground) ColorAt(x, y int16) color.RGBA {
+	if b.Spacing > 0 && y%b.Spacing < b.Thickness {
+		return b.LineColor
+	}
+	return b.Color
+}
+
+// StarsBackground scatters stars of StarColor over Color. About Density
+// pixels out of 1024 are stars, each with its own brightness; Seed selects
+// another sky.
+type StarsBackground struct {
+	Color, StarColor color.RGBA
+	Density          uint16
+	Seed             uint32
+}
+
+// ColorAt returns the star at (x, y) or the sky
+func (b *StarsBackground) ColorAt(x, y int16) color.RGBA {
+	// Integer hash of the position, the same pixel always gets the same value
+	h := uint32(uint16(x)) | uint32(uint16(y))<<16
+	h ^= b.Seed
+	h ^= h >> 16
+	h *= 0x7feb352d
+	h ^= h >> 15
+	h *= 0x846ca68b
+	h ^= h >> 16
+	if uint16(h&1023) >= b.Density {
+		return b.Color
+	}
+	// Brightness from half to full, taken from bits not used above
+	return blendColor(b.Color, b.StarColor, uint8(128+h>>25))
+}
+
+// SetBackground sets the picture drawn behind the eyes and through the
+// eyelids, nil restores the flat background color of SetColors. Every pixel
+// of the viewport is queried on every frame, so keep ColorAt cheap.
+// Halos still fade to the flat background color.
+func (r *RoboEyes) SetBackground(bg Background) {
+	r.background = bg
+	r.frameValid = false
+}
+
+// backgroundSpan paints pixels x0 to x1 of row y with the background,
+// merging runs of identical colors into single spans
+func (r *RoboEyes) backgroundSpan(x0, x1, y int16) {
+	start, c := x0, r.background.ColorAt(x0, y)
+	for x := x0 + 1; x <= x1; x++ {
+		if next := r.background.ColorAt(x, y); next != c {
+			r.paintSpan(start, x-1, y, c)
+			start, c = x, next
+		}
+	}
+	r.paintSpan(start, x1, y, c)
+}
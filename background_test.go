@@ -0,0 +1,92 @@
+package roboeyestinygo
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestBackgroundShowsAroundEyes(t *testing.T) {
+	for i, bg := range []Background{
+		SolidBackground{10, 0, 30, 255},
+		&ScanlinesBackground{Color: color.RGBA{0, 20, 0, 255}, LineColor: color.RGBA{0, 60, 0, 255}, Spacing: 3, Thickness: 1},
+		&StarsBackground{Color: color.RGBA{0, 0, 40, 255}, StarColor: color.RGBA{255, 255, 200, 255}, Density: 40, Seed: 7},
+	} {
+		for _, masks := range []bool{false, true} {
+			for _, mood := range []Mood{MoodTired, MoodAngry, MoodHappy} {
+				d := newTestDevice(128, 64)
+				renderMood(d, mood, func(r *RoboEyes) {
+					r.SetBackground(bg)
+					r.SetLidMasks(masks)
+				})
+				eye := 0
+				for y := int16(0); y < 64; y++ {
+					for x := int16(0); x < 128; x++ {
+						switch c := d.at(x, y); c {
+						case white:
+							eye++
+						case bg.ColorAt(x, y):
+						default:
+							t.Fatalf("background %d masks %v mood %d: pixel %d,%d is %v", i, masks, mood, x, y, c)
+						}
+					}
+				}
+				if eye == 0 {
+					t.Fatalf("background %d masks %v mood %d: no eye drawn", i, masks, mood)
+				}
+			}
+		}
+	}
+}
+
+func TestBackgroundRemoved(t *testing.T) {
+	a, b := newTestDevice(128, 64), newTestDevice(128, 64)
+	renderMood(a, MoodAngry, func(r *RoboEyes) {})
+	r := renderMood(b, MoodAngry, func(r *RoboEyes) { r.SetBackground(SolidBackground{0, 0, 90, 255}) })
+	r.SetBackground(nil)
+	step(r, 1)
+	for i := range a.pix {
+		// The flat background is the cleared buffer or bgColor
+		if (a.pix[i] == white) != (b.pix[i] == white) || b.pix[i].B == 90 {
+			t.Fatalf("pixel %d is %v, want %v", i, b.pix[i], a.pix[i])
+		}
+	}
+}
+
+func TestBitmapBackgroundRepeats(t *testing.T) {
+	bg := &BitmapBackground{Width: 2, Height: 2, Pixels: []color.RGBA{red, green, blue, white}}
+	for _, tc := range []struct {
+		x, y int16
+		want color.RGBA
+	}{
+		{0, 0, red}, {1, 0, green}, {0, 1, blue}, {1, 1, white}, {2, 0, red}, {5, 7, white},
+	} {
+		if c := bg.ColorAt(tc.x, tc.y); c != tc.want {
+			t.Errorf("%d,%d: %v, want %v", tc.x, tc.y, c, tc.want)
+		}
+	}
+	short := &BitmapBackground{Width: 4, Height: 4, Pixels: []color.RGBA{red}}
+	if c := short.ColorAt(3, 3); c != (color.RGBA{}) {
+		t.Errorf("pixel past the data %v", c)
+	}
+}
+
+func TestStarsBackground(t *testing.T) {
+	sky := color.RGBA{0, 0, 40, 255}
+	bg := &StarsBackground{Color: sky, StarColor: white, Density: 64, Seed: 3}
+	stars := 0
+	for y := int16(0); y < 128; y++ {
+		for x := int16(0); x < 128; x++ {
+			c := bg.ColorAt(x, y)
+			if c != bg.ColorAt(x, y) {
+				t.Fatalf("%d,%d changes color", x, y)
+			}
+			if c != sky {
+				stars++
+			}
+		}
+	}
+	// 64 of 1024 pixels
+	if stars < 800 || stars > 1250 {
+		t.Errorf("%d stars in 16384 pixels", stars)
+	}
+}
@@ -0,0 +1,580 @@
+package roboeyestinygo
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Opcode identifies a binary protocol message
+type Opcode byte
+
+const (
+	OpPing            Opcode = 0x01 // no payload
+	OpSetMood         Opcode = 0x02 // mood
+	OpSetDirection    Opcode = 0x03 // direction
+	OpOpen            Opcode = 0x04 // eye mask
+	OpClose           Opcode = 0x05 // eye mask
+	OpBlink           Opcode = 0x06 // eye mask
+	OpAnimLaugh       Opcode = 0x07 // no payload
+	OpAnimConfused    Opcode = 0x08 // no payload
+	OpSetAutoBlinker  Opcode = 0x09 // active [interval:u16 variation:u16]
+	OpSetIdleMode     Opcode = 0x0A // active [interval:u16 variation:u16]
+	OpSetCuriosity    Opcode = 0x0B // active
+	OpSetCyclops      Opcode = 0x0C // active
+	OpSetHFlicker     Opcode = 0x0D // active amplitude:i16
+	OpSetVFlicker     Opcode = 0x0E // active amplitude:i16
+	OpSetSize         Opcode = 0x0F // left:i16 right:i16
+	OpSetBorderRadius Opcode = 0x10 // left right
+	OpSetSpaceBetween Opcode = 0x11 // space:i16
+	OpSetFramerate    Opcode = 0x12 // fps:u16 (1-1000)
+	OpAnimWink        Opcode = 0x13 // eye mask [duration:u16]
+	OpAnimSquint      Opcode = 0x14 // [duration:u16]
+	OpAnimEyeRoll     Opcode = 0x15 // [duration:u16]
+	OpAnimNod         Opcode = 0x16 // count:u16 amplitude:i16 period:u16
+	OpAnimShake       Opcode = 0x17 // count:u16 amplitude:i16 period:u16
+	OpSleep           Opcode = 0x18 // no payload
+	OpWake            Opcode = 0x19 // no payload
+	OpAnimStartle     Opcode = 0x1A // intensity
+	OpGetState        Opcode = 0x20 // no payload, answered with OpState
+
+	OpAck   Opcode = 0xF0 // reply: request opcode, ErrorCode
+	OpState Opcode = 0xF1 // reply: encoded Status
+)
+
+// Eye mask bits used by OpOpen, OpClose and OpBlink, OpAnimWink takes a
+// single eye
+const (
+	EyeLeft  byte = 1 << 0
+	EyeRight byte = 1 << 1
+	EyeBoth       = EyeLeft | EyeRight
+)
+
+// Frame layout: sync, length, opcode, payload..., crc8.
+// The length byte counts the opcode and the payload, the CRC covers
+// length, opcode and payload.
+const (
+	FrameSync       byte = 0xA5
+	FrameOverhead        = 4   // sync + length + opcode + crc
+	MaxFramePayload      = 254 // length byte also counts the opcode
+)
+
+var (
+	ErrFrameIncomplete = errors.New("roboeyes: incomplete frame")
+	ErrFrameSync       = errors.New("roboeyes: missing frame sync byte")
+	ErrFrameLength     = errors.New("roboeyes: invalid frame length")
+	ErrFrameCRC        = errors.New("roboeyes: frame CRC mismatch")
+	ErrPayloadTooLong  = errors.New("roboeyes: frame payload too long")
+	ErrStatusPayload   = errors.New("roboeyes: invalid status payload")
+)
+
+// Frame is a decoded binary protocol message
+type Frame struct {
+	Op      Opcode
+	Payload []byte
+}
+
+// AppendFrame encodes f and appends it to dst
+func AppendFrame(dst []byte, f Frame) ([]byte, error) {
+	if len(f.Payload) > MaxFramePayload {
+		return dst, ErrPayloadTooLong
+	}
+	start := len(dst)
+	dst = append(dst, FrameSync, byte(len(f.Payload)+1), byte(f.Op))
+	dst = append(dst, f.Payload...)
+	return append(dst, crc8(dst[start+1:])), nil
+}
+
+// DecodeFrame decodes the frame at the start of buf and returns it together
+// with the number of bytes consumed. ErrFrameIncomplete means more bytes are
+// needed; on other errors the caller should drop one byte and resynchronize.
+// The returned payload aliases buf.
+func DecodeFrame(buf []byte) (Frame, int, error) {
+	if len(buf) == 0 {
+		return Frame{}, 0, ErrFrameIncomplete
+	}
+	if buf[0] != FrameSync {
+		return Frame{}, 0, ErrFrameSync
+	}
+	if len(buf) < 2 {
+		return Frame{}, 0, ErrFrameIncomplete
+	}
+	length := int(buf[1])
+	if length == 0 {
+		return Frame{}, 0, ErrFrameLength
+	}
+	n := length + 3
+	if len(buf) < n {
+		return Frame{}, 0, ErrFrameIncomplete
+	}
+	if crc8(buf[1:n-1]) != buf[n-1] {
+		return Frame{}, 0, ErrFrameCRC
+	}
+	return Frame{Op: Opcode(buf[2]), Payload: buf[3 : n-1]}, n, nil
+}
+
+// crc8 computes the CRC-8/SMBUS checksum (polynomial 0x07, init 0x00)
+func crc8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// Status is the eyes state reported by OpState
+type Status struct {
+	Mood        Mood
+	Direction   Direction
+	LeftOpen    bool
+	RightOpen   bool
+	AutoBlinker bool
+	Idle        bool
+	Curious     bool
+	Cyclops     bool
+	Laughing    bool
+	Confused    bool
+	Sleeping    bool // Falling asleep or asleep
+}
+
+// Status flag bits of the OpState payload
+const (
+	statusLeftOpen byte = 1 << iota
+	statusRightOpen
+	statusAutoBlinker
+	statusIdle
+	statusCurious
+	statusCyclops
+	statusLaughing
+	statusConfused
+)
+
+// Bits of the second status flags byte, absent from older payloads
+const (
+	statusSleeping byte = 1 << iota
+)
+
+// AppendBinary appends the OpState payload encoding of s to dst
+func (s Status) AppendBinary(dst []byte) []byte {
+	var flags byte
+	for _, f := range [...]struct {
+		set bool
+		bit byte
+	}{
+		{s.LeftOpen, statusLeftOpen},
+		{s.RightOpen, statusRightOpen},
+		{s.AutoBlinker, statusAutoBlinker},
+		{s.Idle, statusIdle},
+		{s.Curious, statusCurious},
+		{s.Cyclops, statusCyclops},
+		{s.Laughing, statusLaughing},
+		{s.Confused, statusConfused},
+	} {
+		if f.set {
+			flags |= f.bit
+		}
+	}
+	return append(dst, byte(s.Mood), byte(s.Direction), flags, boolByte(s.Sleeping)*statusSleeping)
+}
+
+// DecodeStatus decodes an OpState payload, the second flags byte is
+// optional
+func DecodeStatus(payload []byte) (Status, error) {
+	if len(payload) < 3 {
+		return Status{}, ErrStatusPayload
+	}
+	flags := payload[2]
+	var more byte
+	if len(payload) > 3 {
+		more = payload[3]
+	}
+	return Status{
+		Mood:        Mood(payload[0]),
+		Direction:   Direction(payload[1]),
+		LeftOpen:    flags&statusLeftOpen != 0,
+		RightOpen:   flags&statusRightOpen != 0,
+		AutoBlinker: flags&statusAutoBlinker != 0,
+		Idle:        flags&statusIdle != 0,
+		Curious:     flags&statusCurious != 0,
+		Cyclops:     flags&statusCyclops != 0,
+		Laughing:    flags&statusLaughing != 0,
+		Confused:    flags&statusConfused != 0,
+		Sleeping:    more&statusSleeping != 0,
+	}, nil
+}
+
+// Command frame builders for the host side
+
+// MoodFrame builds an OpSetMood request
+func MoodFrame(mood Mood) Frame {
+	return Frame{Op: OpSetMood, Payload: []byte{byte(mood)}}
+}
+
+// DirectionFrame builds an OpSetDirection request
+func DirectionFrame(direction Direction) Frame {
+	return Frame{Op: OpSetDirection, Payload: []byte{byte(direction)}}
+}
+
+// EyesFrame builds an OpOpen, OpClose or OpBlink request for the eyes in mask
+func EyesFrame(op Opcode, mask byte) Frame {
+	return Frame{Op: op, Payload: []byte{mask}}
+}
+
+// ToggleFrame builds a request whose payload is a single active flag,
+// e.g. OpSetAutoBlinker, OpSetIdleMode, OpSetCuriosity or OpSetCyclops
+func ToggleFrame(op Opcode, active bool) Frame {
+	return Frame{Op: op, Payload: []byte{boolByte(active)}}
+}
+
+// IntervalFrame builds an OpSetAutoBlinker or OpSetIdleMode request with
+// interval and variation in full seconds
+func IntervalFrame(op Opcode, active bool, interval, variation uint16) Frame {
+	payload := []byte{boolByte(active), 0, 0, 0, 0}
+	binary.BigEndian.PutUint16(payload[1:], interval)
+	binary.BigEndian.PutUint16(payload[3:], variation)
+	return Frame{Op: op, Payload: payload}
+}
+
+// FlickerFrame builds an OpSetHFlicker or OpSetVFlicker request
+func FlickerFrame(op Opcode, active bool, amplitude int16) Frame {
+	payload := []byte{boolByte(active), 0, 0}
+	binary.BigEndian.PutUint16(payload[1:], uint16(amplitude))
+	return Frame{Op: op, Payload: payload}
+}
+
+// PairFrame builds an OpSetSize or OpSetBorderRadius request,
+// border radii are sent as single bytes
+func PairFrame(op Opcode, left, right int16) Frame {
+	if op == OpSetBorderRadius {
+		return Frame{Op: op, Payload: []byte{byte(left), byte(right)}}
+	}
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint16(payload[0:], uint16(left))
+	binary.BigEndian.PutUint16(payload[2:], uint16(right))
+	return Frame{Op: op, Payload: payload}
+}
+
+// ValueFrame builds an OpSetSpaceBetween or OpSetFramerate request
+func ValueFrame(op Opcode, value uint16) Frame {
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, value)
+	return Frame{Op: op, Payload: payload}
+}
+
+// WinkFrame builds an OpAnimWink request, a zero duration keeps the eye
+// closed for the default time
+func WinkFrame(left bool, duration uint16) Frame {
+	mask := EyeRight
+	if left {
+		mask = EyeLeft
+	}
+	return Frame{Op: OpAnimWink, Payload: appendDuration([]byte{mask}, duration)}
+}
+
+// DurationFrame builds an OpAnimSquint or OpAnimEyeRoll request lasting
+// duration milliseconds, zero for the default duration
+func DurationFrame(op Opcode, duration uint16) Frame {
+	return Frame{Op: op, Payload: appendDuration(nil, duration)}
+}
+
+// StartleFrame builds an OpAnimStartle request
+func StartleFrame(intensity uint8) Frame {
+	return Frame{Op: OpAnimStartle, Payload: []byte{intensity}}
+}
+
+// SwayFrame builds an OpAnimNod or OpAnimShake request for count motions
+// of amplitude pixels lasting period milliseconds each
+func SwayFrame(op Opcode, count uint16, amplitude int16, period uint16) Frame {
+	payload := make([]byte, 6)
+	binary.BigEndian.PutUint16(payload[0:], count)
+	binary.BigEndian.PutUint16(payload[2:], uint16(amplitude))
+	binary.BigEndian.PutUint16(payload[4:], period)
+	return Frame{Op: op, Payload: payload}
+}
+
+// appendDuration appends duration to payload unless it is zero
+func appendDuration(payload []byte, duration uint16) []byte {
+	if duration == 0 {
+		return payload
+	}
+	return binary.BigEndian.AppendUint16(payload, duration)
+}
+
+// BinaryProtocol executes binary frames against a RoboEyes instance.
+// It is meant for setups where the eye board is an I2C/SPI or UART peripheral
+// of a main processor.
+type BinaryProtocol struct {
+	eyes   *RoboEyes
+	locker sync.Locker
+	buf    []byte
+}
+
+// NewBinaryProtocol creates a binary protocol bound to the given eyes
+func NewBinaryProtocol(eyes *RoboEyes) *BinaryProtocol {
+	return &BinaryProtocol{eyes: eyes}
+}
+
+// SetLocker makes every request run while holding l, e.g. a Controller
+func (p *BinaryProtocol) SetLocker(l sync.Locker) {
+	p.locker = l
+}
+
+// Handle executes a request frame and returns the reply frame
+func (p *BinaryProtocol) Handle(f Frame) Frame {
+	if p.locker != nil {
+		p.locker.Lock()
+		defer p.locker.Unlock()
+	}
+	if f.Op == OpGetState {
+		return Frame{Op: OpState, Payload: p.eyes.status().AppendBinary(nil)}
+	}
+	return ackFrame(f.Op, p.execute(f))
+}
+
+// Feed appends received bytes to the internal buffer, executes every complete
+// frame and appends the encoded replies to out. Corrupted frames are answered
+// with a CodeBadFrame acknowledgment and skipped.
+func (p *BinaryProtocol) Feed(data []byte, out []byte) []byte {
+	p.buf = append(p.buf, data...)
+	pos := 0
+	for pos < len(p.buf) {
+		f, n, err := DecodeFrame(p.buf[pos:])
+		if err == ErrFrameIncomplete {
+			// Keep what we have until more bytes arrive
+			break
+		}
+		if err == nil {
+			out, _ = AppendFrame(out, p.Handle(f))
+			pos += n
+			continue
+		}
+		if err != ErrFrameSync {
+			out, _ = AppendFrame(out, ackFrame(0, CodeBadFrame))
+		}
+		// Drop one byte and look for the next sync byte
+		pos++
+	}
+	// Move pending bytes to the start of the buffer so it does not grow
+	p.buf = p.buf[:copy(p.buf, p.buf[pos:])]
+	return out
+}
+
+// Serve reads frames from rd and writes the replies to w until rd is exhausted
+// or a read/write error occurs
+func (p *BinaryProtocol) Serve(rd io.Reader, w io.Writer) error {
+	var in [64]byte
+	var out []byte
+	for {
+		n, err := rd.Read(in[:])
+		if n > 0 {
+			out = p.Feed(in[:n], out[:0])
+			if len(out) > 0 {
+				if _, werr := w.Write(out); werr != nil {
+					return werr
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *BinaryProtocol) execute(f Frame) ErrorCode {
+	r := p.eyes
+	payload := f.Payload
+	switch f.Op {
+	case OpPing:
+	case OpSetMood:
+		if len(payload) < 1 {
+			return CodeMissingArgument
+		}
+		if int(payload[0]) >= len(moodNames) {
+			return CodeInvalidArgument
+		}
+		r.SetMood(Mood(payload[0]))
+	case OpSetDirection:
+		if len(payload) < 1 {
+			return CodeMissingArgument
+		}
+		if int(payload[0]) >= len(directionNames) {
+			return CodeInvalidArgument
+		}
+		r.SetDirection(Direction(payload[0]))
+	case OpOpen, OpClose, OpBlink:
+		mask := EyeBoth
+		if len(payload) > 0 {
+			mask = payload[0]
+		}
+		if mask == 0 || mask&^EyeBoth != 0 {
+			return CodeInvalidArgument
+		}
+		left, right := mask&EyeLeft != 0, mask&EyeRight != 0
+		switch f.Op {
+		case OpOpen:
+			r.OpenEyes(left, right)
+		case OpClose:
+			r.CloseEyes(left, right)
+		default:
+			r.BlinkEyes(left, right)
+		}
+	case OpAnimLaugh:
+		r.AnimLaugh()
+	case OpAnimConfused:
+		r.AnimConfused()
+	case OpAnimWink:
+		if len(payload) < 1 {
+			return CodeMissingArgument
+		}
+		if payload[0] != EyeLeft && payload[0] != EyeRight {
+			return CodeInvalidArgument
+		}
+		duration, code := durationArg(payload[1:], r.winkAnimationDuration)
+		if code != CodeOK {
+			return code
+		}
+		r.AnimWinkWithDuration(payload[0] == EyeLeft, duration)
+	case OpAnimSquint:
+		duration, code := durationArg(payload, r.squintAnimationDuration)
+		if code != CodeOK {
+			return code
+		}
+		r.AnimSquint(duration)
+	case OpAnimEyeRoll:
+		duration, code := durationArg(payload, r.eyeRollAnimationDuration)
+		if code != CodeOK {
+			return code
+		}
+		r.AnimEyeRollWithDuration(duration)
+	case OpAnimNod, OpAnimShake:
+		if len(payload) < 6 {
+			return CodeMissingArgument
+		}
+		count := binary.BigEndian.Uint16(payload[0:])
+		amplitude := int16(binary.BigEndian.Uint16(payload[2:]))
+		period := uint32(binary.BigEndian.Uint16(payload[4:]))
+		if count == 0 || period == 0 {
+			return CodeInvalidArgument
+		}
+		if f.Op == OpAnimNod {
+			r.AnimNod(count, amplitude, period)
+		} else {
+			r.AnimShake(count, amplitude, period)
+		}
+	case OpSleep:
+		r.Sleep()
+	case OpWake:
+		r.Wake()
+	case OpAnimStartle:
+		if len(payload) < 1 {
+			return CodeMissingArgument
+		}
+		r.AnimStartle(payload[0])
+	case OpSetAutoBlinker, OpSetIdleMode:
+		if len(payload) < 1 {
+			return CodeMissingArgument
+		}
+		active := payload[0] != 0
+		set, setWithInterval := r.SetAutoBlinker, r.SetAutoBlinkerWithInterval
+		if f.Op == OpSetIdleMode {
+			set, setWithInterval = r.SetIdleMode, r.SetIdleModeWithInterval
+		}
+		switch len(payload) {
+		case 1:
+			set(active)
+		case 5:
+			setWithInterval(active,
+				uint32(binary.BigEndian.Uint16(payload[1:])),
+				uint32(binary.BigEndian.Uint16(payload[3:])))
+		default:
+			return CodeInvalidArgument
+		}
+	case OpSetCuriosity, OpSetCyclops:
+		if len(payload) < 1 {
+			return CodeMissingArgument
+		}
+		if f.Op == OpSetCuriosity {
+			r.SetCuriosity(payload[0] != 0)
+		} else {
+			r.SetCyclops(payload[0] != 0)
+		}
+	case OpSetHFlicker, OpSetVFlicker:
+		if len(payload) < 3 {
+			return CodeMissingArgument
+		}
+		amplitude := int16(binary.BigEndian.Uint16(payload[1:]))
+		if f.Op == OpSetHFlicker {
+			r.SetHFlicker(payload[0] != 0, amplitude)
+		} else {
+			r.SetVFlicker(payload[0] != 0, amplitude)
+		}
+	case OpSetSize:
+		if len(payload) < 4 {
+			return CodeMissingArgument
+		}
+		left, right := int16(binary.BigEndian.Uint16(payload[0:])), int16(binary.BigEndian.Uint16(payload[2:]))
+		if !r.fitsScreen(left, right, r.spaceBetweenDefault) {
+			return CodeInvalidArgument
+		}
+		r.SetSize(left, right)
+	case OpSetBorderRadius:
+		if len(payload) < 2 {
+			return CodeMissingArgument
+		}
+		r.SetBorderRadius(payload[0], payload[1])
+	case OpSetSpaceBetween:
+		if len(payload) < 2 {
+			return CodeMissingArgument
+		}
+		space := int16(binary.BigEndian.Uint16(payload))
+		if !r.fitsScreen(r.eyeLwidthDefault, r.eyeRwidthDefault, space) {
+			return CodeInvalidArgument
+		}
+		r.SetSpaceBetween(space)
+	case OpSetFramerate:
+		if len(payload) < 2 {
+			return CodeMissingArgument
+		}
+		fps := binary.BigEndian.Uint16(payload)
+		if fps == 0 || fps > maxFrameRate {
+			return CodeInvalidArgument
+		}
+		r.SetFramerate(uint32(fps))
+	default:
+		return CodeUnknownCommand
+	}
+	return CodeOK
+}
+
+// durationArg decodes an optional duration:u16 payload, def when it is empty
+func durationArg(payload []byte, def uint32) (uint32, ErrorCode) {
+	switch len(payload) {
+	case 0:
+		return def, CodeOK
+	case 2:
+		return uint32(binary.BigEndian.Uint16(payload)), CodeOK
+	}
+	return 0, CodeInvalidArgument
+}
+
+func ackFrame(op Opcode, code ErrorCode) Frame {
+	return Frame{Op: OpAck, Payload: []byte{byte(op), byte(code)}}
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
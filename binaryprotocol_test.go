@@ -0,0 +1,274 @@
+package roboeyestinygo
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCRC8(t *testing.T) {
+	// CRC-8/SMBUS check value
+	if crc := crc8([]byte("123456789")); crc != 0xF4 {
+		t.Fatalf("crc8 = %#x, want 0xf4", crc)
+	}
+	if crc := crc8(nil); crc != 0 {
+		t.Fatalf("crc8 of nothing = %#x", crc)
+	}
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte{0x5A}, MaxFramePayload)
+	buf, err := AppendFrame([]byte{0xEE}, Frame{Op: OpSetMood, Payload: payload})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, n, err := DecodeFrame(buf[1:])
+	if err != nil || n != len(buf)-1 || f.Op != OpSetMood || !bytes.Equal(f.Payload, payload) {
+		t.Fatalf("decoded %v %d %v", f.Op, n, err)
+	}
+	if _, err := AppendFrame(nil, Frame{Payload: append(payload, 0)}); err != ErrPayloadTooLong {
+		t.Fatalf("oversized payload: %v", err)
+	}
+}
+
+func TestDecodeFrameErrors(t *testing.T) {
+	frame, _ := AppendFrame(nil, MoodFrame(MoodHappy))
+	corrupted := append([]byte(nil), frame...)
+	corrupted[3] ^= 1
+	for _, c := range []struct {
+		buf []byte
+		err error
+	}{
+		{nil, ErrFrameIncomplete},
+		{[]byte{0x00}, ErrFrameSync},
+		{[]byte{FrameSync}, ErrFrameIncomplete},
+		{[]byte{FrameSync, 0}, ErrFrameLength},
+		{frame[:len(frame)-1], ErrFrameIncomplete},
+		{corrupted, ErrFrameCRC},
+	} {
+		if _, n, err := DecodeFrame(c.buf); err != c.err || n != 0 {
+			t.Errorf("% x: got %v %d, want %v", c.buf, err, n, c.err)
+		}
+	}
+}
+
+// decodeReplies splits encoded reply frames, failing on anything else
+func decodeReplies(t *testing.T, out []byte) []Frame {
+	t.Helper()
+	var replies []Frame
+	for len(out) > 0 {
+		f, n, err := DecodeFrame(out)
+		if err != nil {
+			t.Fatalf("reply % x: %v", out, err)
+		}
+		replies = append(replies, f)
+		out = out[n:]
+	}
+	return replies
+}
+
+func TestBinaryProtocolResync(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	p := NewBinaryProtocol(r)
+
+	// Garbage, a frame with a bad CRC, then valid frames
+	in := []byte{0x00, 0x13, 0xFF}
+	bad, _ := AppendFrame(nil, DirectionFrame(DirE))
+	bad[len(bad)-1] ^= 1
+	in = append(in, bad...)
+	in, _ = AppendFrame(in, MoodFrame(MoodAngry))
+	in, _ = AppendFrame(in, IntervalFrame(OpSetIdleMode, true, 2, 0))
+	in, _ = AppendFrame(in, Frame{Op: OpGetState})
+
+	// Bytes arrive one at a time
+	var out []byte
+	for i := range in {
+		out = p.Feed(in[i:i+1], out)
+	}
+	replies := decodeReplies(t, out)
+	want := []Frame{
+		ackFrame(0, CodeBadFrame),
+		ackFrame(OpSetMood, CodeOK),
+		ackFrame(OpSetIdleMode, CodeOK),
+	}
+	if len(replies) != len(want)+1 {
+		t.Fatalf("%d replies: %v", len(replies), replies)
+	}
+	for i, w := range want {
+		if replies[i].Op != w.Op || !bytes.Equal(replies[i].Payload, w.Payload) {
+			t.Errorf("reply %d: %v % x, want %v % x", i, replies[i].Op, replies[i].Payload, w.Op, w.Payload)
+		}
+	}
+	s, err := DecodeStatus(replies[len(want)].Payload)
+	if err != nil || s.Mood != MoodAngry || s.Direction != DirCenter || !s.Idle {
+		t.Fatalf("status %+v %v", s, err)
+	}
+	if len(p.buf) != 0 {
+		t.Fatalf("%d bytes left pending", len(p.buf))
+	}
+}
+
+func TestBinaryProtocolHandle(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	p := NewBinaryProtocol(r)
+	for _, c := range []struct {
+		f    Frame
+		code ErrorCode
+	}{
+		{Frame{Op: OpPing}, CodeOK},
+		{Frame{Op: 0x7F}, CodeUnknownCommand},
+		{Frame{Op: OpSetMood}, CodeMissingArgument},
+		{MoodFrame(Mood(9)), CodeInvalidArgument},
+		{EyesFrame(OpBlink, 4), CodeInvalidArgument},
+		{EyesFrame(OpOpen, EyeLeft), CodeOK},
+		{Frame{Op: OpSetAutoBlinker, Payload: []byte{1, 0}}, CodeInvalidArgument},
+		{FlickerFrame(OpSetVFlicker, true, 3), CodeOK},
+		{PairFrame(OpSetSize, 80, 80), CodeInvalidArgument},
+		{PairFrame(OpSetSize, 40, 30), CodeOK},
+		{PairFrame(OpSetBorderRadius, 4, 6), CodeOK},
+		{ValueFrame(OpSetSpaceBetween, 100), CodeInvalidArgument},
+		{ValueFrame(OpSetFramerate, 0), CodeInvalidArgument},
+		{ValueFrame(OpSetFramerate, 5000), CodeInvalidArgument},
+	} {
+		reply := p.Handle(c.f)
+		if reply.Op != OpAck || !bytes.Equal(reply.Payload, []byte{byte(c.f.Op), byte(c.code)}) {
+			t.Errorf("%#x % x: reply %v % x, want code %v", c.f.Op, c.f.Payload, reply.Op, reply.Payload, c.code)
+		}
+	}
+	if !r.eyeL_open || r.eyeR_open || r.eyeLwidthDefault != 40 || r.eyeRborderRadiusDefault != 6 || r.vFlickerAmplitude != 3 {
+		t.Fatalf("open %v %v width %d radius %d amplitude %d", r.eyeL_open, r.eyeR_open, r.eyeLwidthDefault, r.eyeRborderRadiusDefault, r.vFlickerAmplitude)
+	}
+}
+
+func TestStatusRoundTrip(t *testing.T) {
+	s := Status{Mood: MoodTired, Direction: DirNW, RightOpen: true, Idle: true, Cyclops: true, Confused: true, Sleeping: true}
+	b := s.AppendBinary(nil)
+	got, err := DecodeStatus(b)
+	if err != nil || got != s {
+		t.Fatalf("got %+v %v, want %+v", got, err, s)
+	}
+	// Payloads without the second flags byte
+	s.Sleeping = false
+	if got, err := DecodeStatus(b[:3]); err != nil || got != s {
+		t.Fatalf("short payload: got %+v %v, want %+v", got, err, s)
+	}
+	if _, err := DecodeStatus([]byte{1}); err != ErrStatusPayload {
+		t.Fatalf("short payload: %v", err)
+	}
+}
+
+func FuzzDecodeFrame(f *testing.F) {
+	valid, _ := AppendFrame(nil, IntervalFrame(OpSetAutoBlinker, true, 3, 1))
+	f.Add(valid, byte(OpSetMood), []byte{2})
+	f.Add([]byte{FrameSync, 0xFF, 0x01}, byte(OpPing), []byte{})
+	f.Add([]byte{0x00, FrameSync}, byte(OpGetState), []byte(nil))
+	f.Fuzz(func(t *testing.T, buf []byte, op byte, payload []byte) {
+		frame, n, err := DecodeFrame(buf)
+		if err == nil {
+			if n < FrameOverhead || n > len(buf) {
+				t.Fatalf("consumed %d of %d bytes", n, len(buf))
+			}
+			// Encoding the frame again gives back the consumed bytes
+			if enc, _ := AppendFrame(nil, frame); !bytes.Equal(enc, buf[:n]) {
+				t.Fatalf("re-encoded % x, want % x", enc, buf[:n])
+			}
+		} else if n != 0 {
+			t.Fatalf("consumed %d bytes on %v", n, err)
+		}
+
+		// Any payload that fits survives AppendFrame then DecodeFrame
+		enc, err := AppendFrame(nil, Frame{Op: Opcode(op), Payload: payload})
+		if len(payload) > MaxFramePayload {
+			if err != ErrPayloadTooLong {
+				t.Fatalf("%d byte payload: %v", len(payload), err)
+			}
+			return
+		}
+		dec, n, err := DecodeFrame(enc)
+		if err != nil || n != len(enc) || dec.Op != Opcode(op) || !bytes.Equal(dec.Payload, payload) {
+			t.Fatalf("round trip of %#x % x: %v % x %d %v", op, payload, dec.Op, dec.Payload, n, err)
+		}
+	})
+}
+
+func FuzzFeed(f *testing.F) {
+	var stream []byte
+	stream, _ = AppendFrame(stream, MoodFrame(MoodHappy))
+	stream, _ = AppendFrame(stream, PairFrame(OpSetSize, 30, 30))
+	stream, _ = AppendFrame(stream, Frame{Op: OpGetState})
+	f.Add(stream, uint8(5))
+	f.Add([]byte{FrameSync, FrameSync, 0x02, byte(OpSetMood)}, uint8(1))
+	f.Fuzz(func(t *testing.T, data []byte, split uint8) {
+		r := newTestEyes(newTestDevice(128, 64), 50)
+		p := NewBinaryProtocol(r)
+		cut := int(split)
+		if cut > len(data) {
+			cut = len(data)
+		}
+		out := p.Feed(data[:cut], nil)
+		out = p.Feed(data[cut:], out)
+
+		// Replies are whole frames and only an incomplete frame is kept
+		decodeReplies(t, out)
+		if len(p.buf) > MaxFramePayload+FrameOverhead-1 {
+			t.Fatalf("%d bytes pending", len(p.buf))
+		}
+		if len(p.buf) > 0 && p.buf[0] != FrameSync {
+			t.Fatalf("pending bytes % x do not start a frame", p.buf)
+		}
+		step(r, 2)
+	})
+}
+
+func TestBinaryProtocolGestures(t *testing.T) {
+	for _, c := range []struct {
+		f       Frame
+		code    ErrorCode
+		playing func(r *RoboEyes) bool
+	}{
+		{WinkFrame(true, 0), CodeOK, func(r *RoboEyes) bool { return r.wink && r.winkLeft }},
+		{WinkFrame(false, 600), CodeOK, func(r *RoboEyes) bool { return r.wink && !r.winkLeft && r.winkAnimationDuration == 600 }},
+		{EyesFrame(OpAnimWink, EyeBoth), CodeInvalidArgument, nil},
+		{Frame{Op: OpAnimWink}, CodeMissingArgument, nil},
+		{DurationFrame(OpAnimSquint, 0), CodeOK, func(r *RoboEyes) bool { return r.squint }},
+		{Frame{Op: OpAnimSquint, Payload: []byte{1}}, CodeInvalidArgument, nil},
+		{DurationFrame(OpAnimEyeRoll, 800), CodeOK, func(r *RoboEyes) bool { return r.eyeRoll && r.eyeRollAnimationDuration == 800 }},
+		{SwayFrame(OpAnimNod, 2, 6, 200), CodeOK, func(r *RoboEyes) bool { return r.nod.active && r.nod.count == 2 && r.nod.period == 200 }},
+		{SwayFrame(OpAnimShake, 1, -10, 400), CodeOK, func(r *RoboEyes) bool { return r.shake.active && r.shake.amplitude == -10 }},
+		{SwayFrame(OpAnimNod, 0, 6, 200), CodeInvalidArgument, nil},
+		{Frame{Op: OpAnimShake, Payload: []byte{0, 1}}, CodeMissingArgument, nil},
+		{StartleFrame(200), CodeOK, func(r *RoboEyes) bool { return r.startle && r.startleIntensity == 200 }},
+		{Frame{Op: OpAnimStartle}, CodeMissingArgument, nil},
+	} {
+		r := newTestEyes(newTestDevice(128, 64), 50)
+		r.Open()
+		reply := NewBinaryProtocol(r).Handle(c.f)
+		if !bytes.Equal(reply.Payload, []byte{byte(c.f.Op), byte(c.code)}) {
+			t.Errorf("%#x % x: reply % x, want code %v", c.f.Op, c.f.Payload, reply.Payload, c.code)
+			continue
+		}
+		step(r, 1)
+		if c.playing != nil && !c.playing(r) {
+			t.Errorf("%#x % x: animation not playing", c.f.Op, c.f.Payload)
+		}
+	}
+}
+
+func TestBinaryProtocolSleep(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	r.Open()
+	p := NewBinaryProtocol(r)
+	sleeping := func() bool {
+		s, err := DecodeStatus(p.Handle(Frame{Op: OpGetState}).Payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.Sleeping
+	}
+	if reply := p.Handle(Frame{Op: OpSleep}); reply.Payload[1] != byte(CodeOK) || !sleeping() {
+		t.Fatalf("sleep: % x", reply.Payload)
+	}
+	step(r, 200)
+	if reply := p.Handle(Frame{Op: OpWake}); reply.Payload[1] != byte(CodeOK) || sleeping() {
+		t.Fatalf("wake: % x", reply.Payload)
+	}
+}
@@ -0,0 +1,178 @@
+package roboeyestinygo
+
+import "image/color"
+
+// ColorSpace selects how colors are interpolated during transitions
+type ColorSpace byte
+
+const (
+	ColorSpaceRGB ColorSpace = iota // Straight channel blend, may pass through grey
+	ColorSpaceHSV                   // Hue rotation along the shortest arc, keeps colors saturated
+)
+
+// Default per-mood eye colors used when the mood palette is enabled
+var defaultMoodColors = [...]color.RGBA{
+	MoodDefault: {255, 255, 255, 255}, // white
+	MoodTired:   {64, 128, 255, 255},  // blue
+	MoodAngry:   {255, 0, 0, 255},     // red
+	MoodHappy:   {255, 220, 0, 255},   // yellow
+}
+
+// SetColors sets the eye and background colors immediately,
+// cancelling any running color transition. While the mood palette is
+// enabled the eyes keep the color of the mood and only get this one back
+// when the palette is disabled.
+func (r *RoboEyes) SetColors(eyes, background color.RGBA) {
+	r.interact()
+	r.baseColor = eyes
+	r.bgColor = background
+	if r.moodColorsActive {
+		return
+	}
+	r.eyesColor = eyes
+	r.colorTo = eyes
+	r.colorTransition = false
+}
+
+// SetMoodColor sets the eye color used for mood when the palette is enabled
+func (r *RoboEyes) SetMoodColor(mood Mood, c color.RGBA) {
+	r.interact()
+	if int(mood) < len(r.moodColors) {
+		r.moodColors[mood] = c
+	}
+	if r.moodColorsActive && mood == r.mood {
+		r.startColorTransition(c)
+	}
+}
+
+// SetMoodColors enables/disables the per-mood eye palette. While enabled,
+// SetMood fades the eyes to the color of the new mood; disabling it fades
+// them back to the color set with SetColors.
+func (r *RoboEyes) SetMoodColors(active bool) {
+	r.interact()
+	r.moodColorsActive = active
+	if active {
+		r.startColorTransition(r.moodColors[r.mood])
+	} else {
+		r.startColorTransition(r.baseColor)
+	}
+}
+
+// SetColorTransition sets the duration in milliseconds and the color space of
+// eye color fades, a duration of 0 switches colors instantly
+func (r *RoboEyes) SetColorTransition(duration uint32, space ColorSpace) {
+	r.colorTransitionDuration = duration
+	r.colorSpace = space
+}
+
+// startColorTransition fades the eyes from their current color to target
+func (r *RoboEyes) startColorTransition(target color.RGBA) {
+	r.colorFrom = r.eyesColor
+	r.colorTo = target
+	r.colorTransitionTimer = r.millis()
+	r.colorTransition = true
+}
+
+// updateColors advances the eye color transition
+func (r *RoboEyes) updateColors(currentTime uint32) {
+	if !r.colorTransition {
+		return
+	}
+	elapsed := currentTime - r.colorTransitionTimer
+	if elapsed >= r.colorTransitionDuration {
+		r.eyesColor = r.colorTo
+		r.colorTransition = false
+		return
+	}
+	t := uint8(elapsed * 255 / r.colorTransitionDuration)
+	if r.colorSpace == ColorSpaceHSV {
+		r.eyesColor = blendHSV(r.colorFrom, r.colorTo, t)
+	} else {
+		r.eyesColor = blendColor(r.colorFrom, r.colorTo, t)
+	}
+}
+
+// blendHSV mixes from and to in HSV space, hue takes the shortest way round
+func blendHSV(from, to color.RGBA, alpha uint8) color.RGBA {
+	h0, s0, v0 := rgbToHSV(from)
+	h1, s1, v1 := rgbToHSV(to)
+
+	// Greys have no hue, borrow the other one so only saturation changes
+	if s0 == 0 {
+		h0 = h1
+	}
+	if s1 == 0 {
+		h1 = h0
+	}
+
+	a := int32(alpha)
+	dh := h1 - h0
+	if dh > hueRange/2 {
+		dh -= hueRange
+	} else if dh < -hueRange/2 {
+		dh += hueRange
+	}
+	h := (h0 + dh*a/255 + hueRange) % hueRange
+	s := s0 + (s1-s0)*a/255
+	v := v0 + (v1-v0)*a/255
+	c := hsvToRGB(h, s, v)
+	c.A = uint8(int32(from.A) + (int32(to.A)-int32(from.A))*a/255)
+	return c
+}
+
+// hueRange is the number of integer hue steps, 256 per sector of the color wheel
+const hueRange = 6 * 256
+
+// rgbToHSV converts c to hue 0-1535, saturation and value 0-255
+func rgbToHSV(c color.RGBA) (h, s, v int32) {
+	r, g, b := int32(c.R), int32(c.G), int32(c.B)
+	v = max(r, g, b)
+	lo := r
+	if g < lo {
+		lo = g
+	}
+	if b < lo {
+		lo = b
+	}
+	delta := v - lo
+	if v == 0 || delta == 0 {
+		return 0, 0, v
+	}
+	s = delta * 255 / v
+	switch v {
+	case r:
+		h = (g - b) * 256 / delta
+	case g:
+		h = 2*256 + (b-r)*256/delta
+	default:
+		h = 4*256 + (r-g)*256/delta
+	}
+	if h < 0 {
+		h += hueRange
+	}
+	return h, s, v
+}
+
+// hsvToRGB converts hue 0-1535, saturation and value 0-255 to an opaque color
+func hsvToRGB(h, s, v int32) color.RGBA {
+	sector, f := h/256, h%256
+	p := v * (255 - s) / 255
+	q := v * (255 - s*f/256) / 255
+	t := v * (255 - s*(256-f)/256) / 255
+	var r, g, b int32
+	switch sector {
+	case 0:
+		r, g, b = v, t, p
+	case 1:
+		r, g, b = q, v, p
+	case 2:
+		r, g, b = p, v, t
+	case 3:
+		r, g, b = p, q, v
+	case 4:
+		r, g, b = t, p, v
+	default:
+		r, g, b = v, p, q
+	}
+	return color.RGBA{uint8(r), uint8(g), uint8(b), 255}
+}
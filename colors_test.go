@@ -0,0 +1,105 @@
+package roboeyestinygo
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestMoodColorsFadeBackToBaseColor(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	r.Open()
+	r.SetMoodColors(true)
+	r.SetMood(MoodAngry)
+	step(r, 50)
+	if r.eyesColor != defaultMoodColors[MoodAngry] {
+		t.Fatalf("angry eyes %v", r.eyesColor)
+	}
+	if c := r.Config().EyesColor; c != white {
+		t.Fatalf("config saved the mood color %v", c)
+	}
+	r.SetMoodColors(false)
+	step(r, 50)
+	if r.eyesColor != white {
+		t.Fatalf("eyes stayed %v with the palette off", r.eyesColor)
+	}
+
+	blue := color.RGBA{0, 0, 255, 255}
+	r.SetColors(blue, color.RGBA{0, 0, 0, 255})
+	r.SetMoodColors(true)
+	step(r, 50)
+	r.SetMoodColors(false)
+	step(r, 50)
+	if r.eyesColor != blue || r.Config().EyesColor != blue {
+		t.Fatalf("eyes %v, config %v", r.eyesColor, r.Config().EyesColor)
+	}
+}
+
+func TestSetColorsKeepsMoodColor(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	r.Open()
+	r.SetMoodColors(true)
+	r.SetMood(MoodAngry)
+	step(r, 50)
+	green := color.RGBA{0, 255, 0, 255}
+	bg := color.RGBA{0, 0, 40, 255}
+	r.SetColors(green, bg)
+	step(r, 50)
+	if r.eyesColor != defaultMoodColors[MoodAngry] || r.bgColor != bg {
+		t.Fatalf("eyes %v background %v", r.eyesColor, r.bgColor)
+	}
+
+	c := r.Config()
+	c.EyesColor = white
+	r.ApplyConfig(c)
+	step(r, 50)
+	if r.eyesColor != defaultMoodColors[MoodAngry] {
+		t.Fatalf("profile load turned the eyes %v", r.eyesColor)
+	}
+
+	r.SetColors(green, bg)
+	r.SetMoodColors(false)
+	step(r, 50)
+	if r.eyesColor != green {
+		t.Fatalf("eyes %v with the palette off", r.eyesColor)
+	}
+}
+
+func TestColorTransition(t *testing.T) {
+	for _, space := range []ColorSpace{ColorSpaceRGB, ColorSpaceHSV} {
+		r := newTestEyes(newTestDevice(128, 64), 50)
+		r.SetColorTransition(400, space)
+		r.SetMoodColors(true)
+		r.SetMood(MoodTired)
+		step(r, 10)
+		if c := r.eyesColor; c == white || c == defaultMoodColors[MoodTired] {
+			t.Errorf("space %d: no intermediate color, %v", space, c)
+		}
+		step(r, 10)
+		if c := r.eyesColor; c != defaultMoodColors[MoodTired] {
+			t.Errorf("space %d: ended on %v", space, c)
+		}
+	}
+}
+
+func TestHSVRoundTrip(t *testing.T) {
+	for _, c := range []color.RGBA{
+		{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 220, 0, 255},
+		{64, 128, 255, 255}, {255, 255, 255, 255}, {0, 0, 0, 255}, {90, 90, 90, 255},
+	} {
+		if got := hsvToRGB(rgbToHSV(c)); diffChannel(got.R, c.R) > 1 || diffChannel(got.G, c.G) > 1 || diffChannel(got.B, c.B) > 1 {
+			t.Errorf("%v came back as %v", c, got)
+		}
+	}
+	// Red to blue goes through magenta, never green
+	mid := blendHSV(color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}, 128)
+	if mid.G != 0 || mid.R < 200 || mid.B < 200 {
+		t.Errorf("red to blue midpoint %v", mid)
+	}
+}
+
+func diffChannel(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
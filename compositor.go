@@ -0,0 +1,104 @@
+package roboeyestinygo
+
+import "time"
+
+// Compositor draws several RoboEyes sharing one device, e.g. two characters
+// side by side on a 320x240 panel. Each instance is initialized with Begin on
+// the shared device and placed with SetViewport; the compositor then clears
+// the buffer once, draws every instance and displays the result once per
+// frame, so that no instance wipes the others.
+//
+// Instances added to a compositor must not be updated directly anymore.
+type Compositor struct {
+	device        DeviceInterface
+	eyes          []*RoboEyes
+	frames        []frameState
+	changed       []bool
+	startTime     time.Time
+	frameInterval uint32
+	fpsTimer      uint32
+}
+
+// NewCompositor returns a compositor drawing the given instances on device
+// at up to frameRate frames per second
+func NewCompositor(device DeviceInterface, frameRate uint32, eyes ...*RoboEyes) *Compositor {
+	c := &Compositor{
+		device:        device,
+		startTime:     time.Now(),
+		frameInterval: 20,
+	}
+	c.SetFramerate(frameRate)
+	for _, r := range eyes {
+		c.Add(r)
+	}
+	return c
+}
+
+// Add draws eyes in the compositor, after the instances already added
+func (c *Compositor) Add(eyes *RoboEyes) {
+	c.eyes = append(c.eyes, eyes)
+	c.frames = append(c.frames, frameState{})
+	c.changed = append(c.changed, false)
+	eyes.Invalidate()
+}
+
+// SetFramerate sets the maximum frame rate, from 1 to 1000 frames per
+// second; other values are ignored
+func (c *Compositor) SetFramerate(fps uint32) {
+	if fps > 0 && fps <= maxFrameRate {
+		c.frameInterval = 1000 / fps
+	}
+}
+
+// Update handles timed updates and animations of all instances
+// Should be called in the main loop
+func (c *Compositor) Update() {
+	currentTime := uint32(time.Since(c.startTime).Milliseconds())
+
+	// Limit updates to defined frame rate
+	if currentTime-c.fpsTimer >= c.frameInterval {
+		c.Draw()
+		c.fpsTimer = currentTime
+	}
+}
+
+// Draw renders every instance and displays the frame. Nothing is drawn when
+// no instance changed; otherwise all of them are redrawn over one clear and
+// devices implementing PartialDisplayer only receive the changed area.
+func (c *Compositor) Draw() {
+	changed := false
+	for i, r := range c.eyes {
+		c.frames[i], c.changed[i] = r.advance()
+		changed = changed || c.changed[i]
+	}
+	if !changed {
+		return
+	}
+
+	// Unchanged instances draw the same pixels again, only the changed ones
+	// contribute to the area sent to the panel
+	c.device.ClearBuffer()
+	var dirty region
+	full := false
+	for i, r := range c.eyes {
+		if !r.fullScreen || r.background != nil {
+			r.clear() // Background of the viewport
+		}
+		r.drawFrame(c.frames[i])
+		if c.changed[i] && r.dirty.width > 0 && r.dirty.height > 0 {
+			x, y, width, height := r.DirtyRegion()
+			dirty = dirty.union(region{x, y, width, height})
+		}
+		full = full || r.fullRefresh
+		r.fullRefresh = false
+	}
+
+	partial, ok := c.device.(PartialDisplayer)
+	if ok && !full {
+		if dirty.width > 0 {
+			partial.DisplayRegion(dirty.x, dirty.y, dirty.width, dirty.height)
+		}
+	} else {
+		c.device.Display()
+	}
+}
@@ -0,0 +1,92 @@
+package roboeyestinygo
+
+import (
+	"image/color"
+	"testing"
+	"time"
+)
+
+// drawComposed draws n frames of the compositor, moving the clock of every
+// instance 20ms forward before each one
+func drawComposed(c *Compositor, n int) {
+	for i := 0; i < n; i++ {
+		for _, r := range c.eyes {
+			r.startTime = r.startTime.Add(-20 * time.Millisecond)
+		}
+		c.Draw()
+	}
+}
+
+func TestCompositorMatchesStandalone(t *testing.T) {
+	d := newFastDevice(320, 240)
+	a := newTestEyes(d, 50)
+	a.SetViewport(0, 0, 160, 240)
+	a.SetAutoScale(true)
+	b := newTestEyes(d, 50)
+	b.SetViewport(160, 40, 160, 160)
+	b.SetAutoScale(true)
+	settle(a)
+	settle(b)
+	a.Open()
+	b.Open()
+	a.SetMood(MoodHappy)
+	b.SetMood(MoodAngry)
+	c := NewCompositor(d, 50, a, b)
+	drawComposed(c, 40)
+
+	ra, rb := newTestDevice(160, 240), newTestDevice(160, 160)
+	renderMood(ra, MoodHappy, func(r *RoboEyes) { r.SetAutoScale(true); settle(r) })
+	renderMood(rb, MoodAngry, func(r *RoboEyes) { r.SetAutoScale(true); settle(r) })
+	bad := 0
+	for y := int16(0); y < 240; y++ {
+		for x := int16(0); x < 320; x++ {
+			var want color.RGBA
+			switch {
+			case x < 160:
+				want = ra.at(x, y)
+			case y >= 40 && y < 200:
+				want = rb.at(x-160, y-40)
+			default:
+				continue
+			}
+			if got := d.at(x, y); got.R != want.R || got.G != want.G || got.B != want.B {
+				bad++
+			}
+		}
+	}
+	if bad != 0 {
+		t.Errorf("%d pixels differ from the instances drawn alone", bad)
+	}
+	if d.displays != 1 {
+		t.Errorf("%d full displays, want only the first frame", d.displays)
+	}
+}
+
+func TestCompositorDisplaysChangedInstance(t *testing.T) {
+	d := newFastDevice(320, 240)
+	a := newTestEyes(d, 50)
+	a.SetViewport(0, 0, 160, 240)
+	b := newTestEyes(d, 50)
+	b.SetViewport(160, 40, 160, 160)
+	a.Open()
+	b.Open()
+	c := NewCompositor(d, 50, a, b)
+	drawComposed(c, 40)
+
+	// Still frames are neither drawn nor displayed
+	regions := len(d.regions)
+	drawComposed(c, 5)
+	if len(d.regions) != regions {
+		t.Fatalf("%d regions sent for still frames", len(d.regions)-regions)
+	}
+
+	// One region per frame, inside the viewport of the changed instance
+	b.Blink()
+	drawComposed(c, 1)
+	if len(d.regions) != regions+1 {
+		t.Fatalf("%d regions sent", len(d.regions)-regions)
+	}
+	if got := d.regions[regions]; got[0] < 160 || got[1] < 40 || got[0]+got[2] > 320 || got[1]+got[3] > 200 {
+		t.Fatalf("region %v outside the blinking instance", got)
+	}
+}
@@ -0,0 +1,224 @@
+package roboeyestinygo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"image/color"
+)
+
+// Binary configuration header: magic "RE", version, body length.
+// New fields are only ever appended to the body, so a decoder reads the
+// fields it knows from newer versions and keeps current values for the
+// fields missing from older versions.
+const (
+	ConfigVersion    byte = 1
+	configMagic0     byte = 'R'
+	configMagic1     byte = 'E'
+	configHeaderSize      = 4
+	configBodySizeV1      = 42
+)
+
+var (
+	ErrConfigFormat  = errors.New("roboeyes: invalid configuration data")
+	ErrConfigVersion = errors.New("roboeyes: unsupported configuration version")
+)
+
+// Config captures every tunable of the eyes, so that a robot profile can be
+// stored in flash or edited with host tools.
+// Intervals are expressed in milliseconds.
+type Config struct {
+	LeftWidth         int16      `json:"leftWidth"`
+	LeftHeight        int16      `json:"leftHeight"`
+	RightWidth        int16      `json:"rightWidth"`
+	RightHeight       int16      `json:"rightHeight"`
+	LeftBorderRadius  byte       `json:"leftBorderRadius"`
+	RightBorderRadius byte       `json:"rightBorderRadius"`
+	SpaceBetween      int16      `json:"spaceBetween"`
+	FrameRate         uint16     `json:"frameRate"`
+	BlinkInterval     uint32     `json:"blinkInterval"`
+	BlinkVariation    uint32     `json:"blinkVariation"`
+	IdleInterval      uint32     `json:"idleInterval"`
+	IdleVariation     uint32     `json:"idleVariation"`
+	HFlickerAmplitude int16      `json:"hFlickerAmplitude"`
+	VFlickerAmplitude int16      `json:"vFlickerAmplitude"`
+	EyesColor         color.RGBA `json:"eyesColor"`
+	BgColor           color.RGBA `json:"bgColor"`
+}
+
+// Config returns the current tunables
+func (r *RoboEyes) Config() Config {
+	fps := r.frameRate
+	if fps > 0xFFFF {
+		fps = 0xFFFF
+	}
+	return Config{
+		LeftWidth:         r.eyeLwidthDefault,
+		LeftHeight:        r.eyeLheightDefault,
+		RightWidth:        r.eyeRwidthDefault,
+		RightHeight:       r.eyeRheightDefault,
+		LeftBorderRadius:  r.eyeLborderRadiusDefault,
+		RightBorderRadius: r.eyeRborderRadiusDefault,
+		SpaceBetween:      r.spaceBetweenDefault,
+		FrameRate:         uint16(fps),
+		BlinkInterval:     r.blinkInterval,
+		BlinkVariation:    r.blinkIntervalVariation,
+		IdleInterval:      r.idleInterval,
+		IdleVariation:     r.idleIntervalVariation,
+		HFlickerAmplitude: r.hFlickerAmplitude,
+		VFlickerAmplitude: r.vFlickerAmplitude,
+		EyesColor:         r.baseColor,
+		BgColor:           r.bgColor,
+	}
+}
+
+// ApplyConfig sets every tunable from c
+func (r *RoboEyes) ApplyConfig(c Config) {
+	r.SetSize(c.LeftWidth, c.RightWidth)
+	r.setHeight(c.LeftHeight, c.RightHeight)
+	r.SetBorderRadius(c.LeftBorderRadius, c.RightBorderRadius)
+	r.SetSpaceBetween(c.SpaceBetween)
+	r.SetFramerate(uint32(c.FrameRate))
+	r.blinkInterval = c.BlinkInterval
+	r.blinkIntervalVariation = c.BlinkVariation
+	r.idleInterval = c.IdleInterval
+	r.idleIntervalVariation = c.IdleVariation
+	r.hFlickerAmplitude = c.HFlickerAmplitude
+	r.vFlickerAmplitude = c.VFlickerAmplitude
+	r.SetColors(c.EyesColor, c.BgColor)
+}
+
+// LoadConfig decodes a JSON or binary configuration and applies it.
+// Fields missing from data keep their current values.
+func (r *RoboEyes) LoadConfig(data []byte) error {
+	c := r.Config()
+	var err error
+	if text := bytes.TrimLeft(data, " \t\r\n"); len(text) > 0 && text[0] == '{' {
+		err = json.Unmarshal(data, &c)
+	} else {
+		err = c.UnmarshalBinary(data)
+	}
+	if err != nil {
+		return err
+	}
+	r.ApplyConfig(c)
+	return nil
+}
+
+// setHeight sets default eye heights and the eyelid limits derived from them
+func (r *RoboEyes) setHeight(left, right int16) {
+	r.eyeLheightDefault = left
+	r.eyeRheightDefault = right
+	// Closed eyes keep their target, they get the new height when reopened
+	if r.eyeLheightNext != 1 {
+		r.eyeLheightNext = left
+	}
+	if r.eyeRheightNext != 1 {
+		r.eyeRheightNext = right
+	}
+	r.eyelidsHeightMax = left / 2
+	r.eyelidsHappyBottomOffsetMax = (left / 2) + r.Scaled(3)
+}
+
+// MarshalBinary encodes c in the compact versioned format
+func (c Config) MarshalBinary() ([]byte, error) {
+	b := make([]byte, configHeaderSize, configHeaderSize+configBodySizeV1)
+	b[0], b[1], b[2], b[3] = configMagic0, configMagic1, ConfigVersion, configBodySizeV1
+	be := binary.BigEndian
+	b = be.AppendUint16(b, uint16(c.LeftWidth))
+	b = be.AppendUint16(b, uint16(c.LeftHeight))
+	b = be.AppendUint16(b, uint16(c.RightWidth))
+	b = be.AppendUint16(b, uint16(c.RightHeight))
+	b = append(b, c.LeftBorderRadius, c.RightBorderRadius)
+	b = be.AppendUint16(b, uint16(c.SpaceBetween))
+	b = be.AppendUint16(b, c.FrameRate)
+	b = be.AppendUint32(b, c.BlinkInterval)
+	b = be.AppendUint32(b, c.BlinkVariation)
+	b = be.AppendUint32(b, c.IdleInterval)
+	b = be.AppendUint32(b, c.IdleVariation)
+	b = be.AppendUint16(b, uint16(c.HFlickerAmplitude))
+	b = be.AppendUint16(b, uint16(c.VFlickerAmplitude))
+	b = append(b, c.EyesColor.R, c.EyesColor.G, c.EyesColor.B, c.EyesColor.A)
+	b = append(b, c.BgColor.R, c.BgColor.G, c.BgColor.B, c.BgColor.A)
+	return b, nil
+}
+
+// UnmarshalBinary decodes data produced by MarshalBinary of any version.
+// Fields unknown to the encoder's version are left untouched in c.
+func (c *Config) UnmarshalBinary(data []byte) error {
+	if len(data) < configHeaderSize || data[0] != configMagic0 || data[1] != configMagic1 {
+		return ErrConfigFormat
+	}
+	if data[2] == 0 {
+		return ErrConfigVersion
+	}
+	size := int(data[3])
+	if len(data) < configHeaderSize+size {
+		return ErrConfigFormat
+	}
+	body := configReader{buf: data[configHeaderSize : configHeaderSize+size]}
+	body.int16(&c.LeftWidth)
+	body.int16(&c.LeftHeight)
+	body.int16(&c.RightWidth)
+	body.int16(&c.RightHeight)
+	body.byte(&c.LeftBorderRadius)
+	body.byte(&c.RightBorderRadius)
+	body.int16(&c.SpaceBetween)
+	body.uint16(&c.FrameRate)
+	body.uint32(&c.BlinkInterval)
+	body.uint32(&c.BlinkVariation)
+	body.uint32(&c.IdleInterval)
+	body.uint32(&c.IdleVariation)
+	body.int16(&c.HFlickerAmplitude)
+	body.int16(&c.VFlickerAmplitude)
+	body.color(&c.EyesColor)
+	body.color(&c.BgColor)
+	return nil
+}
+
+// configReader reads big-endian fields and leaves the destination untouched
+// once the body is exhausted
+type configReader struct {
+	buf []byte
+}
+
+func (cr *configReader) next(n int) []byte {
+	if len(cr.buf) < n {
+		cr.buf = nil
+		return nil
+	}
+	b := cr.buf[:n]
+	cr.buf = cr.buf[n:]
+	return b
+}
+
+func (cr *configReader) byte(v *byte) {
+	if b := cr.next(1); b != nil {
+		*v = b[0]
+	}
+}
+
+func (cr *configReader) uint16(v *uint16) {
+	if b := cr.next(2); b != nil {
+		*v = binary.BigEndian.Uint16(b)
+	}
+}
+
+func (cr *configReader) int16(v *int16) {
+	if b := cr.next(2); b != nil {
+		*v = int16(binary.BigEndian.Uint16(b))
+	}
+}
+
+func (cr *configReader) uint32(v *uint32) {
+	if b := cr.next(4); b != nil {
+		*v = binary.BigEndian.Uint32(b)
+	}
+}
+
+func (cr *configReader) color(v *color.RGBA) {
+	if b := cr.next(4); b != nil {
+		*v = color.RGBA{b[0], b[1], b[2], b[3]}
+	}
+}
@@ -0,0 +1,116 @@
+package roboeyestinygo
+
+import (
+	"encoding/json"
+	"image/color"
+	"testing"
+)
+
+func TestConfigBinaryRoundTrip(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	c := r.Config()
+	c.LeftWidth, c.RightHeight, c.SpaceBetween = 40, 30, 6
+	c.BlinkVariation = 2500
+	c.BgColor = color.RGBA{0, 0, 9, 255}
+	b, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != configHeaderSize+configBodySizeV1 {
+		t.Fatalf("%d bytes", len(b))
+	}
+	var got Config
+	if err := got.UnmarshalBinary(b); err != nil || got != c {
+		t.Fatalf("got %+v %v\nwant %+v", got, err, c)
+	}
+}
+
+func TestConfigBinaryVersions(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	c := r.Config()
+	c.LeftWidth = 40
+	b, _ := c.MarshalBinary()
+
+	// A newer version with an extra trailing field
+	newer := append([]byte(nil), b...)
+	newer[2], newer[3] = ConfigVersion+1, newer[3]+2
+	newer = append(newer, 1, 2)
+	if err := r.LoadConfig(newer); err != nil || r.Config() != c {
+		t.Fatalf("newer version: %v\n%+v\nwant %+v", err, r.Config(), c)
+	}
+
+	// An older version without the colors keeps the current ones
+	r.SetColors(color.RGBA{1, 2, 3, 255}, color.RGBA{4, 5, 6, 255})
+	older := append([]byte(nil), b[:len(b)-8]...)
+	older[3] -= 8
+	if err := r.LoadConfig(older); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Config(); got.EyesColor != (color.RGBA{1, 2, 3, 255}) || got.LeftWidth != 40 {
+		t.Fatalf("older version: %+v", got)
+	}
+
+	for _, data := range [][]byte{nil, []byte("XX\x01\x00"), b[:10]} {
+		if err := r.LoadConfig(data); err != ErrConfigFormat {
+			t.Errorf("% x: %v", data, err)
+		}
+	}
+	if err := r.LoadConfig([]byte("RE\x00\x00")); err != ErrConfigVersion {
+		t.Errorf("version 0: %v", err)
+	}
+}
+
+func TestConfigJSON(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	c := r.Config()
+	js, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Config
+	if err := json.Unmarshal(js, &got); err != nil || got != c {
+		t.Fatalf("got %+v %v", got, err)
+	}
+
+	// Leading whitespace, unknown fields and missing fields are accepted
+	if err := r.LoadConfig([]byte("\n  {\"leftWidth\": 20, \"future\": 3}")); err != nil {
+		t.Fatal(err)
+	}
+	c.LeftWidth = 20
+	if got := r.Config(); got != c {
+		t.Fatalf("got %+v\nwant %+v", got, c)
+	}
+}
+
+func TestConfigFrameRate(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 60)
+	for _, fps := range []uint32{60, 40, 30, 7, 1000} {
+		r.SetFramerate(fps)
+		c := r.Config()
+		if uint32(c.FrameRate) != fps {
+			t.Errorf("%d fps saved as %d", fps, c.FrameRate)
+		}
+		b, _ := c.MarshalBinary()
+		if err := r.LoadConfig(b); err != nil || r.Config().FrameRate != c.FrameRate {
+			t.Errorf("%d fps loaded as %d", fps, r.Config().FrameRate)
+		}
+	}
+	r.SetFramerate(5000)
+	if r.frameRate != 1000 || r.frameInterval != 1 {
+		t.Errorf("5000 fps set %d fps, %d ms per frame", r.frameRate, r.frameInterval)
+	}
+}
+
+func TestConfigHugeVariations(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	c := r.Config()
+	c.BlinkVariation, c.IdleVariation = 3000000000, 0xFFFFFFFF
+	r.ApplyConfig(c)
+	r.SetAutoBlinker(true)
+	r.SetIdleMode(true)
+	r.blinktimer, r.idleAnimationTimer = 0, 0
+	step(r, 2)
+	if r.blinktimer < r.blinkInterval || r.idleAnimationTimer < r.idleInterval {
+		t.Fatalf("blink timer %d idle timer %d", r.blinktimer, r.idleAnimationTimer)
+	}
+}
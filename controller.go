@@ -0,0 +1,209 @@
+package roboeyestinygo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrControllerRunning = errors.New("roboeyes: controller already running")
+
+// Controller runs the render loop of a RoboEyes instance in its own goroutine
+// and serializes every access to it, so the eyes can safely be driven from
+// sensor or communication goroutines while frames are being drawn.
+//
+// The eyes must not be used directly once the controller is started: go
+// through the controller methods, Do, or Lock/Unlock instead.
+type Controller struct {
+	mu     sync.Mutex
+	eyes   *RoboEyes
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewController wraps eyes that were already initialized with Begin
+func NewController(eyes *RoboEyes) *Controller {
+	return &Controller{eyes: eyes}
+}
+
+// Start launches the render loop in a new goroutine.
+// The loop runs until ctx is cancelled or Stop is called.
+func (c *Controller) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done != nil {
+		return ErrControllerRunning
+	}
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+	go c.run(ctx, c.done)
+	return nil
+}
+
+// Stop ends the render loop and waits for it to exit
+func (c *Controller) Stop() {
+	c.mu.Lock()
+	cancel, done := c.cancel, c.done
+	c.mu.Unlock()
+	if done == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// run is the render loop, it sleeps between frames so that other goroutines
+// get scheduled on TinyGo's cooperative scheduler. On exit it marks the
+// controller stopped, so it can be started again after its context ended.
+func (c *Controller) run(ctx context.Context, done chan struct{}) {
+	defer func() {
+		c.mu.Lock()
+		if c.done == done {
+			c.cancel()
+			c.cancel, c.done = nil, nil
+		}
+		c.mu.Unlock()
+		close(done)
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		c.mu.Lock()
+		c.eyes.Update()
+		interval := c.eyes.frameInterval
+		c.mu.Unlock()
+
+		// Always sleep so that other goroutines run on cooperative schedulers
+		time.Sleep(time.Duration(max(interval, 1)) * time.Millisecond)
+	}
+}
+
+// Do runs fn with exclusive access to the eyes
+func (c *Controller) Do(fn func(r *RoboEyes)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn(c.eyes)
+}
+
+// Lock acquires exclusive access to the eyes, it lets the controller be
+// used as a sync.Locker, e.g. by the text and binary protocols
+func (c *Controller) Lock() {
+	c.mu.Lock()
+}
+
+// Unlock releases the access acquired with Lock
+func (c *Controller) Unlock() {
+	c.mu.Unlock()
+}
+
+// SetMood configures eye expression
+func (c *Controller) SetMood(mood Mood) {
+	c.Do(func(r *RoboEyes) { r.SetMood(mood) })
+}
+
+// SetDirection moves eyes to predefined location
+func (c *Controller) SetDirection(direction Direction) {
+	c.Do(func(r *RoboEyes) { r.SetDirection(direction) })
+}
+
+// SetAutoBlinker enables/disables automatic blinking
+func (c *Controller) SetAutoBlinker(active bool) {
+	c.Do(func(r *RoboEyes) { r.SetAutoBlinker(active) })
+}
+
+// SetIdleMode enables/disables random eye movements
+func (c *Controller) SetIdleMode(active bool) {
+	c.Do(func(r *RoboEyes) { r.SetIdleMode(active) })
+}
+
+// SetCuriosity enables/disables curious gaze effect
+func (c *Controller) SetCuriosity(active bool) {
+	c.Do(func(r *RoboEyes) { r.SetCuriosity(active) })
+}
+
+// SetCyclops enables/disables single eye mode
+func (c *Controller) SetCyclops(active bool) {
+	c.Do(func(r *RoboEyes) { r.SetCyclops(active) })
+}
+
+// Open opens both eyes
+func (c *Controller) Open() {
+	c.Do(func(r *RoboEyes) { r.Open() })
+}
+
+// Close closes both eyes
+func (c *Controller) Close() {
+	c.Do(func(r *RoboEyes) { r.Close() })
+}
+
+// Blink performs a blink animation
+func (c *Controller) Blink() {
+	c.Do(func(r *RoboEyes) { r.Blink() })
+}
+
+// AnimConfused triggers confused animation
+func (c *Controller) AnimConfused() {
+	c.Do(func(r *RoboEyes) { r.AnimConfused() })
+}
+
+// AnimLaugh triggers laugh animation
+func (c *Controller) AnimLaugh() {
+	c.Do(func(r *RoboEyes) { r.AnimLaugh() })
+}
+
+// AnimWink triggers a wink of the left or right eye
+func (c *Controller) AnimWink(left bool) {
+	c.Do(func(r *RoboEyes) { r.AnimWink(left) })
+}
+
+// AnimSquint narrows both eyes for duration milliseconds
+func (c *Controller) AnimSquint(duration uint32) {
+	c.Do(func(r *RoboEyes) { r.AnimSquint(duration) })
+}
+
+// AnimEyeRoll triggers eye roll animation
+func (c *Controller) AnimEyeRoll() {
+	c.Do(func(r *RoboEyes) { r.AnimEyeRoll() })
+}
+
+// AnimStartle makes the eyes react to a sudden event
+func (c *Controller) AnimStartle(intensity uint8) {
+	c.Do(func(r *RoboEyes) { r.AnimStartle(intensity) })
+}
+
+// Sleep makes the eyes fall asleep
+func (c *Controller) Sleep() {
+	c.Do(func(r *RoboEyes) { r.Sleep() })
+}
+
+// Wake wakes the eyes up
+func (c *Controller) Wake() {
+	c.Do(func(r *RoboEyes) { r.Wake() })
+}
+
+// AnimNod moves the eyes up and down count times
+func (c *Controller) AnimNod(count uint16, amplitude int16, period uint32) {
+	c.Do(func(r *RoboEyes) { r.AnimNod(count, amplitude, period) })
+}
+
+// AnimShake moves the eyes left and right count times
+func (c *Controller) AnimShake(count uint16, amplitude int16, period uint32) {
+	c.Do(func(r *RoboEyes) { r.AnimShake(count, amplitude, period) })
+}
+
+// Snapshot returns the current state of the eyes
+func (c *Controller) Snapshot() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.eyes.Snapshot()
+}
+
+// Restore applies a state previously returned by Snapshot
+func (c *Controller) Restore(s State) {
+	c.Do(func(r *RoboEyes) { r.Restore(s) })
+}
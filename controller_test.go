@@ -0,0 +1,101 @@
+package roboeyestinygo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestControllerStartStop(t *testing.T) {
+	d := newTestDevice(128, 64)
+	c := NewController(newTestEyes(d, 100))
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Start(context.Background()); err != ErrControllerRunning {
+		t.Fatalf("second Start: %v", err)
+	}
+	c.Stop()
+	c.Stop()
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatalf("Start after Stop: %v", err)
+	}
+	c.Stop()
+}
+
+func TestControllerRestartAfterCancel(t *testing.T) {
+	c := NewController(newTestEyes(newTestDevice(128, 64), 100))
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := c.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	// The loop notices the cancellation within a frame
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		err := c.Start(context.Background())
+		if err == nil {
+			break
+		}
+		if err != ErrControllerRunning || time.Now().After(deadline) {
+			t.Fatalf("Start after cancel: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.Stop()
+}
+
+func TestControllerConcurrentUse(t *testing.T) {
+	d := newTestDevice(128, 64)
+	r := newTestEyes(d, 100)
+	c := NewController(r)
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	p := NewTextProtocol(r)
+	p.SetLocker(c)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				c.SetMood(Mood((g + i) % 4))
+				if reply := p.Execute("DIR ne"); reply != "OK" {
+					t.Errorf("DIR: %s", reply)
+				}
+				c.Blink()
+				time.Sleep(time.Millisecond)
+			}
+		}(g)
+	}
+	wg.Wait()
+	c.SetMood(MoodAngry)
+	if s := c.Snapshot(); s.Mood != MoodAngry || s.Direction != DirNE {
+		t.Fatalf("mood %d direction %d", s.Mood, s.Direction)
+	}
+	c.Stop()
+	if d.displays == 0 {
+		t.Fatal("render loop drew no frame")
+	}
+}
+
+func TestControllerFastFramerate(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 100)
+	r.SetFramerate(5000)
+	if r.frameInterval != 10 {
+		t.Fatalf("5000 fps accepted, %d ms per frame", r.frameInterval)
+	}
+	r.SetFramerate(1000)
+	c := NewController(r)
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	for i := 0; i < 100; i++ {
+		c.SetMood(Mood(i % 4))
+	}
+}
@@ -0,0 +1,149 @@
+package roboeyestinygo
+
+import "image/color"
+
+// frameState holds everything that affects the pixels of a frame.
+// Two equal states render the same picture, so the second one can be skipped.
+type frameState struct {
+	eyeLx, eyeLy, eyeLwidth, eyeLheight int16
+	eyeRx, eyeRy, eyeRwidth, eyeRheight int16
+	eyeLborderRadius, eyeRborderRadius  byte
+	cyclops, antialias, lidMasks        bool
+	dither                              Dither
+	tiredHeight, angryHeight            int16
+	happyOffset                         int16
+	eyesColor, bgColor                  color.RGBA
+	leftStyle, rightStyle               FillStyle
+	zzzStep                             int16
+}
+
+// region is a rectangle in screen coordinates, empty when width or height is 0
+type region struct {
+	x, y, width, height int16
+}
+
+// frameState captures the state of the frame about to be drawn
+func (r *RoboEyes) frameState() frameState {
+	return frameState{
+		eyeLx:            r.eyeLx,
+		eyeLy:            r.eyeLy,
+		eyeLwidth:        r.eyeLwidthCurrent,
+		eyeLheight:       r.eyeLheightCurrent,
+		eyeRx:            r.eyeRx,
+		eyeRy:            r.eyeRy,
+		eyeRwidth:        r.eyeRwidthCurrent,
+		eyeRheight:       r.eyeRheightCurrent,
+		eyeLborderRadius: r.eyeLborderRadiusCurrent,
+		eyeRborderRadius: r.eyeRborderRadiusCurrent,
+		cyclops:          r.cyclops,
+		antialias:        r.antialias,
+		lidMasks:         r.lidMasks,
+		dither:           r.dither,
+		tiredHeight:      r.eyelidsTiredHeight,
+		angryHeight:      r.eyelidsAngryHeight,
+		happyOffset:      r.eyelidsHappyBottomOffset,
+		eyesColor:        r.eyesColor,
+		bgColor:          r.bgColor,
+		leftStyle:        r.fillStyles[0],
+		rightStyle:       r.fillStyles[1],
+		zzzStep:          r.zzzStep,
+	}
+}
+
+// bounds returns the screen area painted by a frame: both eyes plus a one
+// pixel margin for the eyelids, which start above the eye and the happy
+// covers, which are wider than the eye, or the halo width when larger.
+// Zzz letters may be anywhere above the eyes, they take the whole screen.
+func (f frameState) bounds(screenWidth, screenHeight int16) region {
+	if f.zzzStep > 0 {
+		return region{0, 0, screenWidth, screenHeight}
+	}
+	x0, y0 := f.eyeLx, f.eyeLy
+	x1, y1 := f.eyeLx+f.eyeLwidth, f.eyeLy+f.eyeLheight
+	margin := max(1, int16(f.leftStyle.Halo))
+	if !f.cyclops {
+		x0, y0 = min(x0, f.eyeRx), min(y0, f.eyeRy)
+		x1, y1 = max(x1, f.eyeRx+f.eyeRwidth), max(y1, f.eyeRy+f.eyeRheight)
+		margin = max(margin, int16(f.rightStyle.Halo))
+	}
+	return region{x0 - margin, y0 - margin, x1 - x0 + 2*margin, y1 - y0 + 2*margin}.clip(screenWidth, screenHeight)
+}
+
+// clip restricts a region to the screen
+func (a region) clip(screenWidth, screenHeight int16) region {
+	if a.x < 0 {
+		a.width += a.x
+		a.x = 0
+	}
+	if a.y < 0 {
+		a.height += a.y
+		a.y = 0
+	}
+	a.width = min(a.width, screenWidth-a.x)
+	a.height = min(a.height, screenHeight-a.y)
+	if a.width <= 0 || a.height <= 0 {
+		return region{}
+	}
+	return a
+}
+
+// union returns the smallest region containing a and b
+func (a region) union(b region) region {
+	if a.width <= 0 || a.height <= 0 {
+		return b
+	}
+	if b.width <= 0 || b.height <= 0 {
+		return a
+	}
+	x0, y0 := min(a.x, b.x), min(a.y, b.y)
+	x1, y1 := max(a.x+a.width, b.x+b.width), max(a.y+a.height, b.y+b.height)
+	return region{x0, y0, x1 - x0, y1 - y0}
+}
+
+// commitFrame records the frame that was just drawn and the region covering
+// both the previous and the new frame
+func (r *RoboEyes) commitFrame(frame frameState) {
+	switch {
+	case !r.frameValid:
+		r.dirty = region{0, 0, r.screenWidth, r.screenHeight}
+		r.fullRefresh = true
+	case len(r.layers) > 0:
+		// Custom layers may have changed anywhere
+		r.dirty = region{0, 0, r.screenWidth, r.screenHeight}
+	default:
+		current := frame.bounds(r.screenWidth, r.screenHeight)
+		r.dirty = current.union(r.lastFrame.bounds(r.screenWidth, r.screenHeight))
+	}
+	r.lastFrame = frame
+	r.frameValid = true
+}
+
+// display sends the last rendered frame to the panel. Devices implementing
+// PartialDisplayer only receive the dirty region.
+func (r *RoboEyes) display() {
+	partial, ok := r.device.(PartialDisplayer)
+	if ok && !r.fullRefresh {
+		if r.dirty.width > 0 {
+			partial.DisplayRegion(r.DirtyRegion())
+		}
+	} else {
+		r.device.Display()
+	}
+	r.fullRefresh = false
+}
+
+// Invalidate forces the next frame to be fully redrawn and displayed,
+// e.g. after something else has drawn on the device
+func (r *RoboEyes) Invalidate() {
+	r.frameValid = false
+}
+
+// DirtyRegion returns the display area that changed in the last rendered
+// frame in panel coordinates, i.e. after viewport, rotation and mirroring.
+// The region is empty when nothing changed.
+func (r *RoboEyes) DirtyRegion() (x, y, width, height int16) {
+	if r.dirty.width <= 0 || r.dirty.height <= 0 {
+		return 0, 0, 0, 0
+	}
+	return r.toPanelRect(r.dirty.x, r.dirty.y, r.dirty.width, r.dirty.height)
+}
@@ -0,0 +1,78 @@
+package roboeyestinygo
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestDirtyRegionCoversChanges(t *testing.T) {
+	d := newTestDevice(128, 64)
+	r := newTestEyes(d, 50)
+	r.Open()
+	prev := append([]color.RGBA(nil), d.pix...)
+	for i := 0; i < 200; i++ {
+		switch i % 40 {
+		case 5:
+			r.SetDirection(Direction(i % 9))
+		case 15:
+			r.SetMood(Mood(i % 4))
+		case 25:
+			r.Blink()
+		}
+		displays := d.displays
+		step(r, 1)
+		x, y, w, h := r.DirtyRegion()
+		for py := int16(0); py < d.height; py++ {
+			for px := int16(0); px < d.width; px++ {
+				inside := px >= x && px < x+w && py >= y && py < y+h
+				k := int(py)*int(d.width) + int(px)
+				if !inside && d.pix[k] != prev[k] && d.displays != displays {
+					t.Fatalf("frame %d: pixel %d,%d changed outside %d,%d %dx%d", i, px, py, x, y, w, h)
+				}
+			}
+		}
+		copy(prev, d.pix)
+	}
+}
+
+func TestUnchangedFramesAreSkipped(t *testing.T) {
+	d := newTestDevice(128, 64)
+	r := newTestEyes(d, 50)
+	r.Open()
+	step(r, 40)
+	displays := d.displays
+	step(r, 10)
+	if d.displays != displays {
+		t.Fatalf("%d still frames displayed", d.displays-displays)
+	}
+	r.Invalidate()
+	step(r, 1)
+	if d.displays != displays+1 {
+		t.Fatal("invalidated frame not displayed")
+	}
+	r.SetMood(MoodAngry)
+	step(r, 1)
+	if d.displays != displays+2 {
+		t.Fatal("changed frame not displayed")
+	}
+}
+
+func TestPartialDisplay(t *testing.T) {
+	d := newFastDevice(128, 64)
+	r := newTestEyes(d, 50)
+	r.Open()
+	step(r, 40)
+	if d.displays != 1 {
+		t.Fatalf("%d full displays, want only the first frame", d.displays)
+	}
+	regions := len(d.regions)
+	r.SetDirection(DirE)
+	step(r, 1)
+	if len(d.regions) != regions+1 {
+		t.Fatalf("%d regions sent", len(d.regions)-regions)
+	}
+	x, y, w, h := r.DirtyRegion()
+	if got := d.regions[len(d.regions)-1]; got != [4]int16{x, y, w, h} || w == 0 || w == d.width {
+		t.Fatalf("sent %v, dirty region %d,%d %dx%d", got, x, y, w, h)
+	}
+}
@@ -0,0 +1,99 @@
+package roboeyestinygo
+
+import "image/color"
+
+// MonochromeDevice is an optional DeviceInterface extension for 1-bit panels
+// such as SSD1306 or SH1106, which can only switch pixels fully on or off
+type MonochromeDevice interface {
+	Monochrome() bool
+}
+
+// Dither selects the ordered dithering pattern used on monochrome devices
+type Dither byte
+
+const (
+	DitherNone      Dither = iota // Any non-black color lights the pixel
+	DitherBayer4                  // 4x4 Bayer matrix, 17 levels, coarse crosshatch
+	DitherBayer8                  // 8x8 Bayer matrix, 65 levels
+	DitherBlueNoise               // 16x16 blue noise, 256 levels without visible grid
+)
+
+var (
+	ditherOn  = color.RGBA{255, 255, 255, 255}
+	ditherOff = color.RGBA{0, 0, 0, 255}
+)
+
+// bayer8 is the 8x8 Bayer index matrix, its top-left quarter is the 4x4 matrix
+// with every entry multiplied by 4
+var bayer8 = [8][8]uint8{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// blueNoise16 ranks 0-255 generated with the void-and-cluster method
+var blueNoise16 = [16][16]uint8{
+	{234, 50, 188, 19, 58, 171, 121, 47, 163, 3, 247, 104, 22, 132, 14, 65},
+	{209, 8, 118, 97, 240, 205, 23, 228, 138, 64, 123, 170, 72, 224, 99, 149},
+	{85, 139, 229, 165, 78, 146, 111, 84, 176, 216, 30, 231, 153, 201, 42, 180},
+	{25, 62, 195, 29, 43, 185, 7, 249, 41, 100, 191, 48, 87, 5, 128, 243},
+	{221, 152, 101, 253, 130, 220, 59, 200, 156, 12, 136, 112, 254, 174, 69, 109},
+	{46, 189, 2, 73, 172, 90, 142, 116, 80, 237, 210, 61, 147, 33, 206, 160},
+	{81, 124, 217, 113, 208, 15, 241, 27, 168, 45, 178, 20, 193, 96, 225, 18},
+	{242, 164, 60, 35, 157, 53, 181, 68, 223, 105, 125, 83, 236, 131, 55, 141},
+	{197, 10, 227, 134, 246, 95, 126, 198, 148, 1, 244, 161, 71, 9, 182, 106},
+	{40, 93, 179, 75, 192, 6, 218, 36, 91, 57, 202, 34, 215, 155, 233, 74},
+	{252, 120, 150, 24, 110, 63, 166, 119, 232, 183, 133, 103, 49, 117, 31, 167},
+	{16, 212, 51, 238, 207, 137, 255, 21, 76, 151, 13, 250, 190, 88, 203, 135},
+	{102, 184, 82, 169, 38, 89, 187, 52, 204, 98, 173, 67, 129, 4, 222, 56},
+	{230, 144, 0, 127, 226, 11, 154, 114, 239, 39, 219, 28, 235, 145, 175, 77},
+	{196, 37, 248, 70, 107, 199, 66, 177, 17, 143, 115, 159, 86, 44, 108, 26},
+	{122, 92, 158, 214, 140, 32, 245, 94, 213, 79, 194, 54, 211, 186, 251, 162},
+}
+
+// SetDithering selects how intermediate intensities, such as anti-aliased
+// edges, are rendered on devices implementing MonochromeDevice.
+// Other devices are not affected.
+func (r *RoboEyes) SetDithering(mode Dither) {
+	r.dither = mode
+}
+
+// dithering reports whether colors must be dithered before reaching the device
+func (r *RoboEyes) dithering() bool {
+	return r.dither != DitherNone && r.monochrome
+}
+
+// ditherColor maps c to on or off at device pixel (x, y): the pixel is lit
+// when the luminance of c exceeds the pattern threshold at that position
+func (r *RoboEyes) ditherColor(x, y int16, c color.RGBA) color.RGBA {
+	var threshold uint8
+	switch r.dither {
+	case DitherBayer4:
+		threshold = (bayer8[y&3][x&3]>>2)*16 + 8
+	case DitherBayer8:
+		threshold = bayer8[y&7][x&7]*4 + 2
+	default:
+		threshold = uint8(uint16(blueNoise16[y&15][x&15]) * 255 >> 8)
+	}
+	if luminance(c) > threshold {
+		return ditherOn
+	}
+	return ditherOff
+}
+
+// solidColor reports whether c renders the same at every position once
+// dithered, so spans of it can still use the device fast paths
+func solidColor(c color.RGBA) bool {
+	l := luminance(c)
+	return l == 0 || l == 255
+}
+
+// luminance returns the perceived brightness of c, 0-255 (Rec. 601 weights)
+func luminance(c color.RGBA) uint8 {
+	return uint8((uint32(c.R)*299 + uint32(c.G)*587 + uint32(c.B)*114) / 1000)
+}
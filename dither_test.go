@@ -0,0 +1,90 @@
+package roboeyestinygo
+
+import (
+	"image/color"
+	"testing"
+)
+
+// monoDevice is a 1-bit panel
+type monoDevice struct {
+	*testDevice
+}
+
+func (monoDevice) Monochrome() bool { return true }
+
+func TestDitheringOnlyLightsOrDarkensPixels(t *testing.T) {
+	for _, mode := range []Dither{DitherBayer4, DitherBayer8, DitherBlueNoise} {
+		d := newTestDevice(64, 32)
+		r := newTestEyes(monoDevice{d}, 50)
+		r.SetDithering(mode)
+		r.SetAntialiasing(true)
+		r.SetFillStyle(FillStyle{Gradient: GradientVertical, Color: color.RGBA{40, 40, 40, 255}}, FillStyle{})
+		r.Open()
+		r.SetMood(MoodTired)
+		step(r, 30)
+		lit, dark := 0, 0
+		for _, c := range d.pix {
+			switch c {
+			case ditherOn:
+				lit++
+			case ditherOff, color.RGBA{}:
+				dark++
+			default:
+				t.Fatalf("mode %d: pixel %v", mode, c)
+			}
+		}
+		if lit == 0 || dark == 0 {
+			t.Fatalf("mode %d: %d lit and %d dark pixels", mode, lit, dark)
+		}
+	}
+}
+
+func TestDitheringIgnoredOnColorDevices(t *testing.T) {
+	a, b := newTestDevice(64, 32), newTestDevice(64, 32)
+	renderMood(a, MoodAngry, func(r *RoboEyes) { r.SetAntialiasing(true) })
+	renderMood(b, MoodAngry, func(r *RoboEyes) { r.SetAntialiasing(true); r.SetDithering(DitherBayer8) })
+	if n := diffPixels(a, b); n != 0 {
+		t.Fatalf("%d pixels differ", n)
+	}
+}
+
+func TestSetDitheringRedraws(t *testing.T) {
+	d := newTestDevice(64, 32)
+	r := newTestEyes(monoDevice{d}, 50)
+	r.SetAntialiasing(true)
+	r.Open()
+	step(r, 30)
+	if r.Render() {
+		t.Fatal("still frame rendered again")
+	}
+	r.SetDithering(DitherBayer4)
+	if !r.Render() {
+		t.Fatal("frame not redrawn with the new dithering")
+	}
+}
+
+func TestDitherThresholds(t *testing.T) {
+	var r RoboEyes
+	for _, mode := range []Dither{DitherBayer4, DitherBayer8, DitherBlueNoise} {
+		r.dither = mode
+		lit := 0
+		gray := color.RGBA{128, 128, 128, 255}
+		for y := int16(0); y < 16; y++ {
+			for x := int16(0); x < 16; x++ {
+				if r.ditherColor(x, y, color.RGBA{0, 0, 0, 255}) != ditherOff {
+					t.Fatalf("mode %d: black pixel lit", mode)
+				}
+				if r.ditherColor(x, y, white) != ditherOn {
+					t.Fatalf("mode %d: white pixel dark", mode)
+				}
+				if r.ditherColor(x, y, gray) == ditherOn {
+					lit++
+				}
+			}
+		}
+		// Half the pixels of a mid gray are lit
+		if lit < 112 || lit > 144 {
+			t.Errorf("mode %d: %d of 256 gray pixels lit", mode, lit)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package roboeyestinygo
+
+import "image/color"
+
+// BeginDual initializes the eyes on two displays of width x height pixels,
+// one per eye. The displays are driven as the two halves of one screen of
+// twice the width, so gaze, mood and animations stay coordinated and each
+// eye keeps its own eyelid slopes. Eyes are kept centered on their display
+// whatever their size, the space between them is derived from the width.
+// SetRotation and SetMirror apply to each display on its own.
+func (r *RoboEyes) BeginDual(left, right DeviceInterface, width, height int16, frameRate uint32) {
+	r.Begin(&dualDevice{left: left, right: right, width: width, height: height}, 2*width, height, frameRate)
+	r.dualWidth = width
+	r.keepDualSpace()
+
+	// Start centered on each display rather than gliding there
+	r.setDirection(r.direction)
+	r.eyeLx, r.eyeLy = r.eyeLxNext, r.eyeLyNext
+	r.eyeRx, r.eyeRy = r.eyeLx+width, r.eyeLy
+}
+
+// keepDualSpace sets the space between the eyes that keeps each eye
+// centered on its own display in dual mode
+func (r *RoboEyes) keepDualSpace() {
+	if r.dualWidth > 0 {
+		r.spaceBetweenCurrent = r.dualWidth - r.eyeLwidthCurrent
+	}
+}
+
+// orientDual rotates and mirrors both displays of a dual setup, the eyes
+// are laid out again on the rotated displays side by side
+func (r *RoboEyes) orientDual(d *dualDevice, rotation Rotation, mirrorX, mirrorY bool) {
+	d.rotation, d.mirrorX, d.mirrorY = rotation, mirrorX, mirrorY
+	r.panelWidth, r.panelHeight = d.Size()
+	r.dualWidth = r.panelWidth / 2
+	r.keepDualSpace()
+	r.applyTransform()
+}
+
+// dualDevice joins two displays side by side into one DeviceInterface.
+// Optional fast paths are forwarded to each display when it supports them.
+// Each display is rotated and mirrored on its own, the joined screen is
+// made of the two displays as seen after rotation.
+type dualDevice struct {
+	left, right      DeviceInterface
+	width, height    int16 // Size of one display as mounted
+	rotation         Rotation
+	mirrorX, mirrorY bool
+}
+
+// transposed reports whether rows of the joined screen are display columns
+func (d *dualDevice) transposed() bool {
+	return d.rotation == Rotate90 || d.rotation == Rotate270
+}
+
+// screen returns the size of one display after rotation
+func (d *dualDevice) screen() (width, height int16) {
+	if d.transposed() {
+		return d.height, d.width
+	}
+	return d.width, d.height
+}
+
+// toPanel maps a point of one display after rotation to the display
+func (d *dualDevice) toPanel(x, y int16) (int16, int16) {
+	return orient(x, y, d.width, d.height, d.rotation, d.mirrorX, d.mirrorY)
+}
+
+// toPanelRect maps a rectangle of one display after rotation to the display
+func (d *dualDevice) toPanelRect(x, y, width, height int16) (int16, int16, int16, int16) {
+	x0, y0 := d.toPanel(x, y)
+	x1, y1 := d.toPanel(x+width-1, y+height-1)
+	return cornerRect(x0, y0, x1, y1)
+}
+
+// half returns the display showing column x and the column on it
+func (d *dualDevice) half(x int16) (DeviceInterface, int16) {
+	if width, _ := d.screen(); x >= width {
+		return d.right, x - width
+	}
+	return d.left, x
+}
+
+// dualPart is a run of columns on one display, empty when width <= 0
+type dualPart struct {
+	dev      DeviceInterface
+	x, width int16
+}
+
+// split returns the part of columns x to x+width-1 on each display
+func (d *dualDevice) split(x, width int16) [2]dualPart {
+	half, _ := d.screen()
+	x0 := max(x, half)
+	return [2]dualPart{
+		{d.left, x, min(width, half-x)},
+		{d.right, x0 - half, x + width - x0},
+	}
+}
+
+func (d *dualDevice) ClearBuffer() {
+	d.left.ClearBuffer()
+	d.right.ClearBuffer()
+}
+
+func (d *dualDevice) Display() error {
+	errLeft := d.left.Display()
+	errRight := d.right.Display()
+	if errLeft != nil {
+		return errLeft
+	}
+	return errRight
+}
+
+func (d *dualDevice) SetPixel(x, y int16, c color.RGBA) {
+	dev, x := d.half(x)
+	x, y = d.toPanel(x, y)
+	dev.SetPixel(x, y, c)
+}
+
+func (d *dualDevice) Size() (width, height int16) {
+	width, height = d.screen()
+	return 2 * width, height
+}
+
+// FillRectangle uses the displays' rectangle fill, pixel by pixel otherwise
+func (d *dualDevice) FillRectangle(x, y, width, height int16, c color.RGBA) error {
+	var err error
+	for _, p := range d.split(x, width) {
+		if p.width <= 0 {
+			continue
+		}
+		if e := d.fill(p, y, height, c); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// fill paints rows y to y+height-1 of a part with the display's rectangle
+// fill, pixel by pixel otherwise
+func (d *dualDevice) fill(p dualPart, y, height int16, c color.RGBA) error {
+	x, y, width, height := d.toPanelRect(p.x, y, p.width, height)
+	if rf, ok := p.dev.(RectangleFiller); ok {
+		return rf.FillRectangle(x, y, width, height, c)
+	}
+	for j := y; j < y+height; j++ {
+		for i := x; i < x+width; i++ {
+			p.dev.SetPixel(i, j, c)
+		}
+	}
+	return nil
+}
+
+// DrawFastHLine uses the displays' line drawing, pixel by pixel otherwise.
+// Rows of rotated displays are columns and are filled as rectangles.
+func (d *dualDevice) DrawFastHLine(x0, x1, y int16, c color.RGBA) {
+	for _, p := range d.split(x0, x1-x0+1) {
+		if p.width <= 0 {
+			continue
+		}
+		if hl, ok := p.dev.(HLineDrawer); ok && !d.transposed() {
+			px, py, width, _ := d.toPanelRect(p.x, y, p.width, 1)
+			hl.DrawFastHLine(px, px+width-1, py, c)
+			continue
+		}
+		d.fill(p, y, 1, c)
+	}
+}
+
+// DisplayRegion updates the part of the region on each display, displays
+// without partial updates are fully refreshed when the region touches them
+func (d *dualDevice) DisplayRegion(x, y, width, height int16) error {
+	var err error
+	for _, p := range d.split(x, width) {
+		if p.width <= 0 {
+			continue
+		}
+		var e error
+		if pd, ok := p.dev.(PartialDisplayer); ok {
+			e = pd.DisplayRegion(d.toPanelRect(p.x, y, p.width, height))
+		} else {
+			e = p.dev.Display()
+		}
+		if e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Monochrome reports whether both displays are 1-bit
+func (d *dualDevice) Monochrome() bool {
+	return monochrome(d.left) && monochrome(d.right)
+}
+
+// monochrome reports whether dev declared itself 1-bit
+func monochrome(dev DeviceInterface) bool {
+	mono, ok := dev.(MonochromeDevice)
+	return ok && mono.Monochrome()
+}
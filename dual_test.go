@@ -0,0 +1,74 @@
+package roboeyestinygo
+
+import "testing"
+
+// renderDual draws a settled frame of the mood on two displays
+func renderDual(left, right DeviceInterface, width, height int16, mood Mood, setup func(r *RoboEyes)) *RoboEyes {
+	r := &RoboEyes{}
+	r.BeginDual(left, right, width, height, 50)
+	setup(r)
+	settle(r)
+	r.Open()
+	r.SetMood(mood)
+	step(r, 40)
+	return r
+}
+
+func TestDualEyesAreCentered(t *testing.T) {
+	for _, mood := range []Mood{MoodDefault, MoodAngry, MoodTired, MoodHappy} {
+		a, b := newTestDevice(64, 64), newFastDevice(64, 64)
+		renderDual(a, b, 64, 64, mood, func(r *RoboEyes) {})
+		for i, d := range []*testDevice{a, &b.testDevice} {
+			x0, x1 := int16(64), int16(-1)
+			for y := int16(0); y < 64; y++ {
+				for x := int16(0); x < 64; x++ {
+					if d.at(x, y) == white {
+						x0, x1 = min(x0, x), max(x1, x)
+					}
+				}
+			}
+			if x1 < 0 || x0-(63-x1) > 1 || (63-x1)-x0 > 1 {
+				t.Errorf("mood %d display %d: eye from column %d to %d", mood, i, x0, x1)
+			}
+		}
+	}
+}
+
+func TestDualOrientation(t *testing.T) {
+	refLeft, refRight := newTestDevice(128, 64), newTestDevice(128, 64)
+	renderDual(refLeft, refRight, 128, 64, MoodAngry, func(r *RoboEyes) {})
+	for _, tc := range []struct {
+		rotation         Rotation
+		mirrorX, mirrorY bool
+	}{
+		{Rotate0, true, false}, {Rotate0, false, true}, {Rotate90, false, false},
+		{Rotate180, false, false}, {Rotate270, true, false},
+	} {
+		width, height := int16(128), int16(64)
+		if tc.rotation == Rotate90 || tc.rotation == Rotate270 {
+			width, height = height, width
+		}
+		left, right := newTestDevice(width, height), newFastDevice(width, height)
+		r := renderDual(left, right, width, height, MoodAngry, func(r *RoboEyes) {
+			r.SetRotation(tc.rotation)
+			r.SetMirror(tc.mirrorX, tc.mirrorY)
+		})
+		if w, h := r.Size(); w != 256 || h != 64 {
+			t.Fatalf("%+v: screen %dx%d", tc, w, h)
+		}
+		// Each display shows its own eye as the unrotated display does
+		d := r.device.(*dualDevice)
+		bad := 0
+		for y := int16(0); y < 64; y++ {
+			for x := int16(0); x < 128; x++ {
+				px, py := d.toPanel(x, y)
+				if left.at(px, py) != refLeft.at(x, y) || right.at(px, py) != refRight.at(x, y) {
+					bad++
+				}
+			}
+		}
+		if bad != 0 {
+			t.Errorf("%+v: %d pixels differ", tc, bad)
+		}
+	}
+}
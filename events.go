@@ -0,0 +1,120 @@
+package roboeyestinygo
+
+// EventType identifies what an Event reports
+type EventType byte
+
+const (
+	EventBlinkStart        EventType = iota // Eyes started a blink, Left and Right tell which
+	EventBlinkEnd                           // Eyes of the blink are open again
+	EventAnimationDone                      // Animation ended or was Interrupted
+	EventMoodChanged                        // Mood switched to Mood
+	EventGazeTargetChanged                  // Eyes started moving towards X, Y
+	EventFellAsleep                         // Eyes closed at the end of the falling asleep sequence
+	EventWokeUp                             // Eyes are awake again
+	EventFrameRendered                      // A changed frame was drawn into the device buffer
+)
+
+// Animation identifies a triggered animation in EventAnimationDone events
+type Animation byte
+
+const (
+	AnimationLaugh Animation = iota
+	AnimationConfused
+	AnimationWink
+	AnimationSquint
+	AnimationEyeRoll
+	AnimationNod
+	AnimationShake
+	AnimationStartle
+)
+
+// Event names for logs
+var (
+	eventNames     = [...]string{"blink start", "blink end", "animation done", "mood changed", "gaze target changed", "fell asleep", "woke up", "frame rendered"}
+	animationNames = [...]string{"laugh", "confused", "wink", "squint", "eye roll", "nod", "shake", "startle"}
+)
+
+// Event reports something that happened to the eyes, fields other than Type
+// are only set for the event types using them
+type Event struct {
+	Type        EventType
+	Animation   Animation // EventAnimationDone
+	Interrupted bool      // EventAnimationDone: stopped before its end by another animation
+	Mood        Mood      // EventMoodChanged
+	X, Y        int16     // EventGazeTargetChanged: target position of the left eye
+	Left, Right bool      // EventBlinkStart and EventBlinkEnd: blinking eyes
+}
+
+// String returns the name of the event type
+func (t EventType) String() string {
+	if int(t) < len(eventNames) {
+		return eventNames[t]
+	}
+	return "unknown event"
+}
+
+// String returns the name of the animation
+func (a Animation) String() string {
+	if int(a) < len(animationNames) {
+		return animationNames[a]
+	}
+	return "unknown animation"
+}
+
+// OnEvent sets the function called for every event, nil removes it. It is
+// called from Update, DrawEyes or Render while the frame is computed, so it
+// must return quickly. It may call RoboEyes methods, but not the methods of
+// a Controller running these eyes, whose lock is held.
+func (r *RoboEyes) OnEvent(handler func(Event)) {
+	r.onEvent = handler
+}
+
+// emit calls the event handler
+func (r *RoboEyes) emit(e Event) {
+	if r.onEvent != nil {
+		r.onEvent(e)
+	}
+}
+
+// blink closes and reopens specified eyes
+func (r *RoboEyes) blink(left, right bool) {
+	r.closeEyes(left, right)
+	r.openEyes(left, right)
+	r.blinking = true
+	r.blinkLeft, r.blinkRight = left, right
+	r.emit(Event{Type: EventBlinkStart, Left: left, Right: right})
+}
+
+// trackBlink reports the end of a blink once the eyes have reopened, which
+// is when they are within a pixel of the height they are opening to
+func (r *RoboEyes) trackBlink() {
+	if !r.blinking {
+		return
+	}
+	// The right eye is not drawn in cyclops mode
+	right := r.blinkRight && !r.cyclops
+	if r.blinkLeft && (r.eyeLheightNext == 1 || r.eyeLheightCurrent+1 < r.eyeLheightNext) {
+		return
+	}
+	if right && (r.eyeRheightNext == 1 || r.eyeRheightCurrent+1 < r.eyeRheightNext) {
+		return
+	}
+	r.blinking = false
+	r.emit(Event{Type: EventBlinkEnd, Left: r.blinkLeft, Right: r.blinkRight})
+}
+
+// animationDone reports the end of an animation and frees its channel
+func (r *RoboEyes) animationDone(a Animation, interrupted bool) {
+	if channel, ok := a.channel(); ok {
+		ch := &r.channels[channel]
+		if ch.busy && ch.playing.animation == a {
+			ch.busy = false
+		}
+	}
+	r.emit(Event{Type: EventAnimationDone, Animation: a, Interrupted: interrupted})
+}
+
+// gazeTargetChanged reports the new position the eyes move to
+func (r *RoboEyes) gazeTargetChanged() {
+	r.emit(Event{Type: EventGazeTargetChanged, X: r.eyeLxNext, Y: r.eyeLyNext})
+}
@@ -0,0 +1,131 @@
+package roboeyestinygo
+
+import "testing"
+
+// recordEvents returns eyes whose events other than rendered frames are
+// appended to the returned slice
+func recordEvents() (*RoboEyes, *[]Event, *int) {
+	var events []Event
+	frames := 0
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	r.OnEvent(func(e Event) {
+		if e.Type == EventFrameRendered {
+			frames++
+			return
+		}
+		events = append(events, e)
+	})
+	return r, &events, &frames
+}
+
+func TestBlinkEvents(t *testing.T) {
+	r, events, _ := recordEvents()
+	r.Open()
+	step(r, 20)
+	*events = nil
+	r.BlinkEyes(false, true)
+	step(r, 20)
+	want := []Event{
+		{Type: EventBlinkStart, Right: true},
+		{Type: EventBlinkEnd, Right: true},
+	}
+	if len(*events) != len(want) || (*events)[0] != want[0] || (*events)[1] != want[1] {
+		t.Fatalf("events %+v", *events)
+	}
+}
+
+func TestMoodAndGazeEvents(t *testing.T) {
+	r, events, _ := recordEvents()
+	r.Open()
+	step(r, 20)
+	*events = nil
+	r.SetMood(MoodHappy)
+	r.SetMood(MoodHappy)
+	r.SetDirection(DirN)
+	step(r, 1)
+	want := []Event{
+		{Type: EventMoodChanged, Mood: MoodHappy},
+		{Type: EventGazeTargetChanged, X: r.eyeLxNext, Y: r.eyeLyNext},
+	}
+	if len(*events) != len(want) || (*events)[0] != want[0] || (*events)[1] != want[1] {
+		t.Fatalf("events %+v\nwant %+v", *events, want)
+	}
+}
+
+func TestAnimationDoneEvents(t *testing.T) {
+	r, events, _ := recordEvents()
+	r.Open()
+	step(r, 20)
+	*events = nil
+	r.AnimLaugh()
+	step(r, 40)
+	r.AnimWink(false)
+	step(r, 2)
+	r.AnimStartle(100)
+	step(r, 80)
+	var done []Event
+	for _, e := range *events {
+		if e.Type == EventAnimationDone {
+			done = append(done, e)
+		}
+	}
+	want := []Event{
+		{Type: EventAnimationDone, Animation: AnimationLaugh},
+		{Type: EventAnimationDone, Animation: AnimationWink, Interrupted: true},
+		{Type: EventAnimationDone, Animation: AnimationStartle},
+	}
+	if len(done) != len(want) {
+		t.Fatalf("events %+v", done)
+	}
+	for i := range want {
+		if done[i] != want[i] {
+			t.Errorf("event %d: %+v, want %+v", i, done[i], want[i])
+		}
+	}
+}
+
+func TestSleepEvents(t *testing.T) {
+	r, events, _ := recordEvents()
+	r.Open()
+	r.Sleep()
+	step(r, 200)
+	if e := (*events)[len(*events)-1]; e.Type != EventFellAsleep {
+		t.Fatalf("last event %v, want fell asleep", e.Type)
+	}
+	r.Wake()
+	step(r, 100)
+	if e := (*events)[len(*events)-1]; e.Type != EventWokeUp {
+		t.Fatalf("last event %v, want woke up", e.Type)
+	}
+}
+
+func TestFrameRenderedEvents(t *testing.T) {
+	r, _, frames := recordEvents()
+	r.Open()
+	step(r, 40)
+	n := *frames
+	step(r, 10)
+	if *frames != n {
+		t.Fatalf("%d still frames reported", *frames-n)
+	}
+	r.SetMood(MoodAngry)
+	step(r, 1)
+	if *frames != n+1 {
+		t.Fatalf("%d frames reported, want 1", *frames-n)
+	}
+}
+
+func TestEventNames(t *testing.T) {
+	if s := EventGazeTargetChanged.String(); s != "gaze target changed" {
+		t.Errorf("event name %q", s)
+	}
+	if s := AnimationEyeRoll.String(); s != "eye roll" {
+		t.Errorf("animation name %q", s)
+	}
+	if s := EventType(200).String(); s != "unknown event" {
+		t.Errorf("unknown event name %q", s)
+	}
+	if len(eventNames) != int(EventFrameRendered)+1 || len(animationNames) != int(AnimationStartle)+1 {
+		t.Errorf("%d event names, %d animation names", len(eventNames), len(animationNames))
+	}
+}
@@ -34,6 +34,11 @@ func (d *SH1106Display) Size() (int16, int16) {
 	return d.device.Size()
 }
 
+// Monochrome tells RoboEyes the panel is 1-bit, so shading gets dithered
+func (d *SH1106Display) Monochrome() bool {
+	return true
+}
+
 const (
 	width  = 128
 	height = 64
@@ -64,6 +69,9 @@ func main() {
 	eyes := roboeyestinygo.RoboEyes{}
 	eyes.Begin(adapter, width, height, 50) // 128x64 OLED @ 50 FPS
 	// eyes.Debug()
+	// eyes.SetRotation(roboeyestinygo.Rotate180) // Display mounted upside down
+	// eyes.SetMirror(true, false)                // Module wired mirrored horizontally
+	// eyes.SetAutoScale(true)                    // Scale the 128x64 design to other display sizes
 	// Set expressions
 	// eyes.SetDirection(roboeyestinygo.DirCenter)
 	// eyes.SetMood(roboeyestinygo.Mood)
@@ -71,8 +79,13 @@ func main() {
 	eyes.SetAutoBlinkerWithInterval(true, 3, 2) // Blink every 3-5 seconds
 	eyes.SetIdleModeWithInterval(true, 2, 2)    // Start idle animation cycle (eyes looking in random directions) -> turn on/off, set interval between each eye repositioning in full seconds, set range for random time interval variation in full seconds
 	// eyes.SetCuriosity(true)
+	// eyes.SetSleepTimeout(60) // Fall asleep after a minute without interaction
+	// eyes.SetZzz(true)
 	// eyes.SetIdleMode(true)
 	// eyes.SetCyclops(true)
+	// eyes.SetAntialiasing(true)
+	// eyes.SetDithering(roboeyestinygo.DitherBayer4) // Render anti-aliased edges as dither patterns
+	// eyes.SetBackground(&roboeyestinygo.ScanlinesBackground{LineColor: color.RGBA{0, 40, 0, 255}, Spacing: 2, Thickness: 1})
 
 	for {
 		eyes.Update()
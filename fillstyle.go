@@ -0,0 +1,190 @@
+package roboeyestinygo
+
+import "image/color"
+
+// Gradient selects how the body of an eye is shaded
+type Gradient byte
+
+const (
+	GradientNone     Gradient = iota // Flat eyesColor
+	GradientVertical                 // eyesColor at the top to Color at the bottom
+	GradientRadial                   // eyesColor in the center to Color at the rim
+)
+
+// FillStyle describes how an eye shape is painted.
+// The zero value is the classic flat eyesColor fill.
+type FillStyle struct {
+	Gradient  Gradient   // Shading of the eye body
+	Color     color.RGBA // End color of the gradient
+	Glow      byte       // Width in pixels of the inner glow along the rim, 0 disables it
+	GlowColor color.RGBA // Color of the inner glow at the rim
+	Halo      byte       // Width in pixels of the outer halo, 0 disables it
+	HaloColor color.RGBA // Color of the outer halo next to the eye, fading to bgColor
+}
+
+// Number of precomputed gradient colors per eye
+const gradientLevels = 64
+
+// eyeShader computes the color of every pixel of a styled eye. Gradient
+// colors are precomputed in a lookup table that is only rebuilt when the
+// style or the eye color changes, so shading a pixel costs a few integer
+// operations and consecutive pixels of the same color are sent as one span.
+type eyeShader struct {
+	style               FillStyle
+	from                color.RGBA // eyesColor, start of the gradient
+	under               color.RGBA // Color around the eye, for anti-aliased corners
+	x, y, width, height int16
+	radius              int16
+	kx, ky              int32 // Radial gradient scale factors, 16.16 fixed point
+	lut                 [gradientLevels]color.RGBA
+	lutGradient         Gradient
+	lutFrom, lutTo      color.RGBA
+}
+
+// shaderRow holds what a row of the eye shares: the rim on both sides and
+// the distance to the top or bottom rim
+type shaderRow struct {
+	left, right int16
+	vertical    int16
+}
+
+// SetFillStyle sets the fill style of the left and right eye.
+// Eyelids are drawn over styled eyes the same way as over flat ones.
+func (r *RoboEyes) SetFillStyle(left, right FillStyle) {
+	r.interact()
+	r.fillStyles[0] = left
+	r.fillStyles[1] = right
+}
+
+// visibleShaders returns the shaders of the drawn eyes, only the left one
+// in cyclops mode
+func (r *RoboEyes) visibleShaders() []eyeShader {
+	if r.cyclops {
+		return r.shaders[:1]
+	}
+	return r.shaders[:]
+}
+
+// prepare sets the geometry and colors of the eye for the coming frame
+func (s *eyeShader) prepare(style FillStyle, x, y, width, height, radius int16, eyes, background color.RGBA) {
+	s.style = style
+	s.from = eyes
+	s.under = background
+	if style.Halo > 0 {
+		s.under = style.HaloColor
+	}
+	s.x, s.y, s.width, s.height = x, y, width, height
+	s.radius = min(radius, width/2, height/2)
+	if s.radius < 1 {
+		s.radius = 0
+	}
+
+	if style.Gradient == GradientRadial {
+		// Squared distance from the center, reaching the last level on the rim
+		// of the ellipse inscribed in the eye. Coordinates are doubled so the
+		// center can fall between two pixels.
+		s.kx = (gradientLevels - 1) << 16 / max(int32(width)*int32(width), 1)
+		s.ky = (gradientLevels - 1) << 16 / max(int32(height)*int32(height), 1)
+	}
+	if style.Gradient != GradientNone &&
+		(s.lutGradient != style.Gradient || s.lutFrom != eyes || s.lutTo != style.Color) {
+		s.buildLUT()
+	}
+}
+
+// plain reports whether the eye is drawn with a single color
+func (s *eyeShader) plain() bool {
+	return s.style.Gradient == GradientNone && s.style.Glow == 0
+}
+
+// buildLUT precomputes the gradient colors. Radial gradients are indexed by
+// squared distance, so each level is placed at the square root of its index.
+func (s *eyeShader) buildLUT() {
+	for i := range s.lut {
+		t := int32(i) * 255 / (gradientLevels - 1)
+		if s.style.Gradient == GradientRadial {
+			t = int32(isqrt(t * 255))
+		}
+		s.lut[i] = blendColor(s.from, s.style.Color, uint8(t))
+	}
+	s.lutGradient = s.style.Gradient
+	s.lutFrom = s.from
+	s.lutTo = s.style.Color
+}
+
+// row returns the rim positions of row y
+func (s *eyeShader) row(y int16) shaderRow {
+	top, bottom := y-s.y, s.y+s.height-1-y
+	vertical := max(min(top, bottom), 0)
+	inset := int16(0)
+	if vertical < s.radius {
+		// Rows crossing the rounded corners are shorter
+		dy := int32(s.radius - vertical)
+		inset = s.radius - isqrt(int32(s.radius)*int32(s.radius)-dy*dy)
+	}
+	return shaderRow{s.x + inset, s.x + s.width - 1 - inset, vertical}
+}
+
+// colorAt returns the color of pixel (x, y) of row
+func (s *eyeShader) colorAt(x, y int16, row *shaderRow) color.RGBA {
+	c := s.from
+	switch s.style.Gradient {
+	case GradientVertical:
+		c = s.lut[int32(y-s.y)*(gradientLevels-1)/int32(max(s.height-1, 1))]
+	case GradientRadial:
+		dx := int32(2*x + 1 - 2*s.x - s.width)
+		dy := int32(2*y + 1 - 2*s.y - s.height)
+		c = s.lut[min(int16((dx*dx*s.kx+dy*dy*s.ky)>>16), gradientLevels-1)]
+	}
+
+	if glow := int16(s.style.Glow); glow > 0 {
+		d := max(min(x-row.left, row.right-x, row.vertical), 0)
+		if d < glow {
+			c = blendColor(s.style.GlowColor, c, uint8(int32(d)*255/int32(glow)))
+		}
+	}
+	return c
+}
+
+// shade returns the color of a single pixel of the eye
+func (s *eyeShader) shade(x, y int16) color.RGBA {
+	row := s.row(y)
+	return s.colorAt(x, y, &row)
+}
+
+// shadeSpan paints pixels x0 to x1 of row y with the active shader,
+// merging runs of identical colors into single spans
+func (r *RoboEyes) shadeSpan(x0, x1, y int16) {
+	s := r.shading
+	row := s.row(y)
+	start, c := x0, s.colorAt(x0, y, &row)
+	for x := x0 + 1; x <= x1; x++ {
+		if next := s.colorAt(x, y, &row); next != c {
+			r.paintSpan(start, x-1, y, c)
+			start, c = x, next
+		}
+	}
+	r.paintSpan(start, x1, y, c)
+}
+
+// drawHalo paints the outer halo of an eye as concentric rounded rectangles,
+// outermost first, fading from HaloColor next to the eye to the background
+func (r *RoboEyes) drawHalo(s *eyeShader) {
+	n := int16(s.style.Halo)
+	for k := n; k >= 1; k-- {
+		c := blendColor(s.style.HaloColor, r.bgColor, uint8(int32(k-1)*255/int32(n)))
+		r.fillRoundRect(s.x-k, s.y-k, s.width+2*k, s.height+2*k, s.radius+k, c)
+	}
+}
+
+// isqrt returns the integer square root of n, rounded down
+func isqrt(n int32) int16 {
+	if n <= 0 {
+		return 0
+	}
+	x, y := n, (n+1)/2
+	for y < x {
+		x, y = y, (y+n/y)/2
+	}
+	return int16(x)
+}
@@ -0,0 +1,81 @@
+package roboeyestinygo
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestZeroFillStyleIsFlat(t *testing.T) {
+	for _, mood := range []Mood{MoodDefault, MoodTired, MoodAngry, MoodHappy} {
+		a, b := newTestDevice(128, 64), newTestDevice(128, 64)
+		renderMood(a, mood, func(r *RoboEyes) {})
+		renderMood(b, mood, func(r *RoboEyes) { r.SetFillStyle(FillStyle{}, FillStyle{}) })
+		if n := diffPixels(a, b); n != 0 {
+			t.Errorf("mood %d: %d pixels differ", mood, n)
+		}
+	}
+}
+
+func TestGradientFill(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	d := newTestDevice(128, 64)
+	r := renderMood(d, MoodDefault, func(r *RoboEyes) {
+		r.SetFillStyle(FillStyle{Gradient: GradientVertical, Color: black}, FillStyle{Gradient: GradientRadial, Color: black})
+	})
+
+	// Vertical: brightness falls from top to bottom of the left eye
+	x, y, w, h := r.LeftEye()
+	cx := x + w/2
+	prev := 256
+	for py := y; py < y+h; py++ {
+		c := d.at(cx, py)
+		if int(c.R) > prev {
+			t.Fatalf("row %d: %v brighter than the row above", py, c)
+		}
+		prev = int(c.R)
+	}
+	if top, bottom := d.at(cx, y), d.at(cx, y+h-1); top.R < 240 || bottom.R > 15 {
+		t.Errorf("vertical gradient from %v to %v", top, bottom)
+	}
+
+	// Radial: the center of the right eye is brighter than its rim
+	x, y, w, h = r.RightEye()
+	center, rim := d.at(x+w/2, y+h/2), d.at(x+w/2, y)
+	if center.R < 240 || rim.R >= center.R {
+		t.Errorf("radial gradient center %v, rim %v", center, rim)
+	}
+}
+
+func TestGlowAndHalo(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	glow, halo := color.RGBA{0, 0, 200, 255}, color.RGBA{0, 200, 0, 255}
+	d := newTestDevice(128, 64)
+	r := renderMood(d, MoodDefault, func(r *RoboEyes) {
+		r.SetColors(white, black)
+		r.SetFillStyle(FillStyle{Glow: 4, GlowColor: glow}, FillStyle{Halo: 3, HaloColor: halo})
+	})
+
+	// Glow tints the rim of the left eye only
+	x, y, w, h := r.LeftEye()
+	if c := d.at(x+w/2, y+h/2); c != white {
+		t.Errorf("left eye center %v", c)
+	}
+	if c := d.at(x+1, y+h/2); c.B == 0 {
+		t.Errorf("left eye rim %v", c)
+	}
+	if c := d.at(x-2, y+h/2); c.B != 0 {
+		t.Errorf("outside the left eye %v", c)
+	}
+
+	// Halo tints the background next to the right eye
+	x, y, w, h = r.RightEye()
+	if c := d.at(x-1, y+h/2); c.G == 0 || c.R != 0 {
+		t.Errorf("next to the right eye %v", c)
+	}
+	if c := d.at(x+w+10, y+h/2); c.G != 0 {
+		t.Errorf("away from the right eye %v", c)
+	}
+	if c := d.at(x+w/2, y+h/2); c != white {
+		t.Errorf("right eye center %v", c)
+	}
+}
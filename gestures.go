@@ -0,0 +1,256 @@
+package roboeyestinygo
+
+// AnimWink triggers wink animation: the left or right eye closes for a
+// moment while the head seems to tilt towards it. Cyclops eyes just blink.
+func (r *RoboEyes) AnimWink(left bool) {
+	r.AnimWinkWithDuration(left, r.winkAnimationDuration)
+}
+
+// AnimWinkWithDuration triggers wink animation keeping the eye closed for
+// duration milliseconds
+func (r *RoboEyes) AnimWinkWithDuration(left bool, duration uint32) {
+	r.interact()
+	r.schedule(animationCall{animation: AnimationWink, left: left, duration: duration})
+}
+
+// AnimSquint narrows both eyes to half their height for duration milliseconds
+func (r *RoboEyes) AnimSquint(duration uint32) {
+	r.interact()
+	r.schedule(animationCall{animation: AnimationSquint, duration: duration})
+}
+
+// AnimEyeRoll triggers eye roll animation: the gaze travels from the left
+// over the top to the right, then back to where it was
+func (r *RoboEyes) AnimEyeRoll() {
+	r.AnimEyeRollWithDuration(r.eyeRollAnimationDuration)
+}
+
+// AnimEyeRollWithDuration triggers eye roll animation lasting duration
+// milliseconds
+func (r *RoboEyes) AnimEyeRollWithDuration(duration uint32) {
+	r.interact()
+	r.schedule(animationCall{animation: AnimationEyeRoll, duration: duration})
+}
+
+// animateWink closes the winking eye, tilts the eyes while it is closed and
+// reopens it when the wink is over
+func (r *RoboEyes) animateWink(currentTime uint32) {
+	if !r.wink {
+		return
+	}
+	left := r.winkLeft || r.cyclops
+	if r.winkToggle {
+		r.closeEyes(left, !left)
+		r.winkAnimationTimer = currentTime
+		r.winkToggle = false
+	} else if currentTime >= r.winkAnimationTimer+r.winkAnimationDuration {
+		r.openEyes(left, !left)
+		r.winkToggle = true
+		r.wink = false
+		r.animationDone(AnimationWink, false)
+		return
+	}
+
+	// Lower the winking eye and raise the other one a little
+	if !r.cyclops {
+		tilt := r.Scaled(1)
+		if !left {
+			tilt = -tilt
+		}
+		r.eyeLy += tilt
+		r.eyeRy -= tilt
+	}
+}
+
+// animateSquint keeps open eyes at half their height while squinting and
+// restores their height afterwards, eyes reopening from a blink included
+func (r *RoboEyes) animateSquint(currentTime uint32) {
+	if !r.squint {
+		return
+	}
+	if r.squintToggle {
+		r.squintAnimationTimer = currentTime
+		r.squintToggle = false
+	} else if currentTime >= r.squintAnimationTimer+r.squintAnimationDuration {
+		r.squintToggle = true
+		r.squint = false
+		r.animationDone(AnimationSquint, false)
+	}
+
+	left, right := r.eyeLheightDefault, r.eyeRheightDefault
+	if r.squint {
+		left, right = left-left/2, right-right/2
+	}
+	// Closed eyes are left to the blink that reopens them
+	if r.eyeLheightNext != 1 {
+		r.eyeLheightNext = left
+	}
+	if r.eyeRheightNext != 1 {
+		r.eyeRheightNext = right
+	}
+}
+
+// animateEyeRoll moves the gaze along a half ellipse spanning the screen,
+// idle mode waits for the eye roll
+func (r *RoboEyes) animateEyeRoll(currentTime uint32) {
+	if !r.eyeRoll {
+		return
+	}
+	if r.eyeRollToggle {
+		r.eyeRollFromX, r.eyeRollFromY = r.eyeLxNext, r.eyeLyNext
+		r.eyeRollAnimationTimer = currentTime
+		r.eyeRollToggle = false
+	}
+	elapsed := currentTime - r.eyeRollAnimationTimer
+	if elapsed >= r.eyeRollAnimationDuration {
+		// Look where the eyes were looking before
+		r.eyeLxNext, r.eyeLyNext = r.eyeRollFromX, r.eyeRollFromY
+		r.eyeRollToggle = true
+		r.eyeRoll = false
+		r.animationDone(AnimationEyeRoll, false)
+		r.gazeTargetChanged()
+		return
+	}
+
+	angle := int32(uint64(elapsed) * angleTurn / 2 / uint64(r.eyeRollAnimationDuration))
+	maxX := int32(r.GetScreenConstraintX())
+	maxY := int32(r.GetScreenConstraintY())
+	r.eyeLxNext = int16(maxX * (sineUnit - icos(angle)) / (2 * sineUnit))
+	r.eyeLyNext = int16(maxY * (sineUnit - isin(angle)) / (2 * sineUnit))
+}
+
+// sway is a sinusoidal motion of the eye pair along one axis
+type sway struct {
+	active    bool
+	toggle    bool // Motion starts on the next frame
+	timer     uint32
+	count     uint16
+	amplitude int16
+	period    uint32
+	offset    int16 // Offset applied to the current frame
+}
+
+// AnimNod moves the eyes down and up count times, amplitude pixels each way,
+// in period milliseconds per nod
+func (r *RoboEyes) AnimNod(count uint16, amplitude int16, period uint32) {
+	r.interact()
+	if count > 0 && period > 0 {
+		r.schedule(animationCall{animation: AnimationNod, count: count, amplitude: amplitude, period: period})
+	}
+}
+
+// AnimShake moves the eyes right and left count times, amplitude pixels each
+// way, in period milliseconds per shake
+func (r *RoboEyes) AnimShake(count uint16, amplitude int16, period uint32) {
+	r.interact()
+	if count > 0 && period > 0 {
+		r.schedule(animationCall{animation: AnimationShake, count: count, amplitude: amplitude, period: period})
+	}
+}
+
+// start plays the motion from the next frame
+func (s *sway) start(count uint16, amplitude int16, period uint32) {
+	s.active = count > 0 && period > 0
+	s.toggle = true
+	s.count = count
+	s.amplitude = amplitude
+	s.period = period
+}
+
+// update returns the offset of the motion at currentTime, 0 once it is over.
+// Whole periods are played so the motion ends where it started.
+func (s *sway) update(currentTime uint32) int16 {
+	if !s.active {
+		return 0
+	}
+	if s.toggle {
+		s.timer = currentTime
+		s.toggle = false
+	}
+	elapsed := currentTime - s.timer
+	if elapsed >= s.period*uint32(s.count) {
+		s.active = false
+		return 0
+	}
+	phase := int32(uint64(elapsed%s.period) * angleTurn / uint64(s.period))
+	v := int32(s.amplitude) * isin(phase)
+	// Round half away from zero
+	if v < 0 {
+		v -= sineUnit / 2
+	} else {
+		v += sineUnit / 2
+	}
+	return int16(v / sineUnit)
+}
+
+// removeSway takes the nod and shake offsets of the last frame back out of
+// the eye positions, so smoothing only follows the gaze
+func (r *RoboEyes) removeSway() {
+	r.eyeLx -= r.shake.offset
+	r.eyeRx -= r.shake.offset
+	r.eyeLy -= r.nod.offset
+	r.eyeRy -= r.nod.offset
+	r.nod.offset, r.shake.offset = 0, 0
+}
+
+// applySway moves both eyes by the nod and shake offsets of the frame
+func (r *RoboEyes) applySway(currentTime uint32) {
+	nodding, shaking := r.nod.active, r.shake.active
+	r.nod.offset = r.nod.update(currentTime)
+	r.shake.offset = r.shake.update(currentTime)
+	if nodding && !r.nod.active {
+		r.animationDone(AnimationNod, false)
+	}
+	if shaking && !r.shake.active {
+		r.animationDone(AnimationShake, false)
+	}
+	r.eyeLx += r.shake.offset
+	r.eyeRx += r.shake.offset
+	r.eyeLy += r.nod.offset
+	r.eyeRy += r.nod.offset
+}
+
+// Angles of isin and icos are in 1/1024 of a turn, results are scaled to
+// sineUnit so gestures need no floating point
+const (
+	angleTurn = 1024
+	sineUnit  = 1 << 14
+)
+
+// quarterSine holds sin(i*pi/128) for i = 0 to 64, scaled to sineUnit
+var quarterSine = [65]uint16{
+	0, 402, 804, 1205, 1606, 2006, 2404, 2801,
+	3196, 3590, 3981, 4370, 4756, 5139, 5520, 5897,
+	6270, 6639, 7005, 7366, 7723, 8076, 8423, 8765,
+	9102, 9434, 9760, 10080, 10394, 10702, 11003, 11297,
+	11585, 11866, 12140, 12406, 12665, 12916, 13160, 13395,
+	13623, 13842, 14053, 14256, 14449, 14635, 14811, 14978,
+	15137, 15286, 15426, 15557, 15679, 15791, 15893, 15986,
+	16069, 16143, 16207, 16261, 16305, 16340, 16364, 16379,
+	16384,
+}
+
+// isin returns the sine of angle, interpolating the quarter wave table
+func isin(angle int32) int32 {
+	angle &= angleTurn - 1
+	negative := angle >= angleTurn/2
+	angle &= angleTurn/2 - 1
+	if angle > angleTurn/4 {
+		angle = angleTurn/2 - angle
+	}
+	// Four angle steps per table entry, linear in between
+	i, f := angle>>2, angle&3
+	v := int32(quarterSine[i])
+	if f > 0 {
+		v += (int32(quarterSine[i+1]) - v) * f / 4
+	}
+	if negative {
+		return -v
+	}
+	return v
+}
+
+// icos returns the cosine of angle
+func icos(angle int32) int32 {
+	return isin(angle + angleTurn/4)
+}
@@ -0,0 +1,110 @@
+package roboeyestinygo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIntegerSine(t *testing.T) {
+	for angle := int32(-angleTurn); angle <= 2*angleTurn; angle++ {
+		rad := 2 * math.Pi * float64(angle) / angleTurn
+		if d := float64(isin(angle)) - sineUnit*math.Sin(rad); d < -2 || d > 2 {
+			t.Fatalf("isin(%d) = %d, off by %.1f", angle, isin(angle), d)
+		}
+		if d := float64(icos(angle)) - sineUnit*math.Cos(rad); d < -2 || d > 2 {
+			t.Fatalf("icos(%d) = %d, off by %.1f", angle, icos(angle), d)
+		}
+	}
+}
+
+func TestWink(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	r.Open()
+	step(r, 20)
+	r.AnimWink(true)
+	step(r, 6)
+	// Heights glide towards their target and may stop a pixel short
+	if r.eyeLheightCurrent > 2 || r.eyeRheightCurrent < r.eyeRheightDefault-1 {
+		t.Fatalf("winking eyes %d and %d high", r.eyeLheightCurrent, r.eyeRheightCurrent)
+	}
+	step(r, 30)
+	if r.wink || r.eyeLheightCurrent < r.eyeLheightDefault-1 {
+		t.Fatalf("left eye %d high after the wink", r.eyeLheightCurrent)
+	}
+}
+
+func TestSquint(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	r.Open()
+	step(r, 20)
+	r.AnimSquint(400)
+	step(r, 10)
+	half := r.eyeLheightDefault - r.eyeLheightDefault/2
+	if r.eyeLheightCurrent > half || r.eyeRheightCurrent > half || r.eyeLheightCurrent < half-1 {
+		t.Fatalf("squinting eyes %d and %d high, want %d", r.eyeLheightCurrent, r.eyeRheightCurrent, half)
+	}
+	step(r, 20)
+	if r.squint || r.eyeLheightCurrent < r.eyeLheightDefault-1 {
+		t.Fatalf("eyes %d high after squinting", r.eyeLheightCurrent)
+	}
+}
+
+func TestEyeRoll(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	r.Open()
+	step(r, 30)
+	x, y := r.eyeLxNext, r.eyeLyNext
+	r.AnimEyeRollWithDuration(1000)
+	top, left, right := false, false, false
+	for i := 0; i < 50; i++ {
+		step(r, 1)
+		left = left || r.eyeLxNext == 0
+		right = right || r.eyeLxNext >= r.GetScreenConstraintX()-1
+		top = top || r.eyeLyNext == 0 && r.eyeLxNext > 0 && r.eyeLxNext < r.GetScreenConstraintX()
+	}
+	if !top || !left || !right {
+		t.Errorf("eye roll reached left %v, top %v, right %v", left, top, right)
+	}
+	step(r, 5)
+	if r.eyeRoll || r.eyeLxNext != x || r.eyeLyNext != y {
+		t.Errorf("gaze at %d,%d after the roll, want %d,%d", r.eyeLxNext, r.eyeLyNext, x, y)
+	}
+}
+
+func TestSwayOffsets(t *testing.T) {
+	s := sway{}
+	s.start(2, 6, 200)
+	if got := s.update(1000); got != 0 {
+		t.Fatalf("first offset %d", got)
+	}
+	for ms := uint32(0); ms < 400; ms += 10 {
+		want := int16(math.Round(6 * math.Sin(2*math.Pi*float64(ms%200)/200)))
+		if got := s.update(1000 + ms); got != want {
+			t.Errorf("%dms: offset %d, want %d", ms, got, want)
+		}
+	}
+	if got := s.update(1400); got != 0 || s.active {
+		t.Errorf("offset %d after two periods", got)
+	}
+}
+
+func TestNodAndShake(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	r.Open()
+	step(r, 30)
+	x, y := r.eyeLx, r.eyeLy
+	r.AnimNod(2, 6, 200)
+	r.AnimShake(1, 10, 400) // Played after the nod
+	minX, maxX, minY, maxY := x, x, y, y
+	for i := 0; i < 50; i++ {
+		step(r, 1)
+		minX, maxX = min(minX, r.eyeLx), max(maxX, r.eyeLx)
+		minY, maxY = min(minY, r.eyeLy), max(maxY, r.eyeLy)
+	}
+	if maxX-minX < 16 || maxY-minY < 10 {
+		t.Errorf("eyes moved %d across and %d down", maxX-minX, maxY-minY)
+	}
+	if r.nod.active || r.shake.active || r.eyeLx != x || r.eyeLy != y {
+		t.Errorf("eyes at %d,%d after the motion, want %d,%d", r.eyeLx, r.eyeLy, x, y)
+	}
+}
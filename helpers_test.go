@@ -0,0 +1,135 @@
+package roboeyestinygo
+
+import (
+	"image/color"
+	"time"
+)
+
+var white = color.RGBA{255, 255, 255, 255}
+
+// testDevice is an in-memory display recording every pixel
+type testDevice struct {
+	width, height int16
+	pix           []color.RGBA
+	displays      int
+}
+
+func newTestDevice(width, height int16) *testDevice {
+	return &testDevice{width: width, height: height, pix: make([]color.RGBA, int(width)*int(height))}
+}
+
+func (d *testDevice) ClearBuffer() {
+	for i := range d.pix {
+		d.pix[i] = color.RGBA{}
+	}
+}
+
+func (d *testDevice) Display() error {
+	d.displays++
+	return nil
+}
+
+func (d *testDevice) SetPixel(x, y int16, c color.RGBA) {
+	if x >= 0 && y >= 0 && x < d.width && y < d.height {
+		d.pix[int(y)*int(d.width)+int(x)] = c
+	}
+}
+
+func (d *testDevice) Size() (int16, int16) {
+	return d.width, d.height
+}
+
+func (d *testDevice) at(x, y int16) color.RGBA {
+	return d.pix[int(y)*int(d.width)+int(x)]
+}
+
+// spanDevice records the horizontal spans drawn through the HLineDrawer path
+type spanDevice struct {
+	testDevice
+	spans [][3]int16
+}
+
+func (d *spanDevice) DrawFastHLine(x0, x1, y int16, c color.RGBA) {
+	d.spans = append(d.spans, [3]int16{x0, x1, y})
+}
+
+// newTestEyes starts eyes on dev with the given frame rate
+func newTestEyes(dev DeviceInterface, fps uint32) *RoboEyes {
+	r := &RoboEyes{}
+	width, height := dev.Size()
+	r.Begin(dev, width, height, fps)
+	return r
+}
+
+// step draws n frames, moving the clock 20ms forward before each one
+func step(r *RoboEyes, n int) {
+	for i := 0; i < n; i++ {
+		r.startTime = r.startTime.Add(-20 * time.Millisecond)
+		r.DrawEyes()
+	}
+}
+
+// settle moves the eyes to their target position at once, they glide to a
+// new layout otherwise and may stop a pixel short of it
+func settle(r *RoboEyes) {
+	r.eyeLx, r.eyeLy = r.eyeLxNext, r.eyeLyNext
+	r.eyeRx, r.eyeRy = r.eyeLx+r.eyeLwidthCurrent+r.spaceBetweenCurrent, r.eyeLy
+}
+
+// fastDevice only draws through the rectangle and span fast paths and
+// records the regions sent with DisplayRegion
+type fastDevice struct {
+	testDevice
+	rects, lines int
+	regions      [][4]int16
+}
+
+func newFastDevice(width, height int16) *fastDevice {
+	return &fastDevice{testDevice: *newTestDevice(width, height)}
+}
+
+func (d *fastDevice) FillRectangle(x, y, width, height int16, c color.RGBA) error {
+	d.rects++
+	if x < 0 || y < 0 || width <= 0 || height <= 0 || x+width > d.width || y+height > d.height {
+		panic("rectangle outside the device")
+	}
+	for j := y; j < y+height; j++ {
+		for i := x; i < x+width; i++ {
+			d.testDevice.SetPixel(i, j, c)
+		}
+	}
+	return nil
+}
+
+func (d *fastDevice) DrawFastHLine(x0, x1, y int16, c color.RGBA) {
+	d.lines++
+	if x0 < 0 || y < 0 || x0 > x1 || x1 >= d.width || y >= d.height {
+		panic("span outside the device")
+	}
+	for x := x0; x <= x1; x++ {
+		d.testDevice.SetPixel(x, y, c)
+	}
+}
+
+func (d *fastDevice) SetPixel(x, y int16, c color.RGBA) {
+	if x < 0 || y < 0 || x >= d.width || y >= d.height {
+		panic("pixel outside the device")
+	}
+	d.testDevice.SetPixel(x, y, c)
+}
+
+func (d *fastDevice) DisplayRegion(x, y, width, height int16) error {
+	d.regions = append(d.regions, [4]int16{x, y, width, height})
+	return nil
+}
+
+// diffPixels returns the number of pixels that differ between a and b
+func diffPixels(a, b *testDevice) int {
+	n := 0
+	for i := range a.pix {
+		if a.pix[i] != b.pix[i] {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,113 @@
+package roboeyestinygo
+
+import "image/color"
+
+// Z-levels of the built-in layers. Custom layers are drawn in ascending z
+// order, after the built-in layer of the same level.
+const (
+	LayerBackground = 0   // Cleared screen, custom layers below it are drawn on top of it too
+	LayerEyes       = 100 // Eye shapes
+	LayerLids       = 200 // Eyelids, masking the layers from LayerEyes up to here
+	LayerOverlay    = 300 // Suggested level for overlays above everything
+)
+
+// Drawable is a custom layer drawn with the eyes on every frame
+type Drawable interface {
+	Draw(s Surface)
+}
+
+// DrawableFunc adapts an ordinary function to a Drawable
+type DrawableFunc func(s Surface)
+
+// Draw calls f(s)
+func (f DrawableFunc) Draw(s Surface) {
+	f(s)
+}
+
+// Surface is the screen drawables paint on. Coordinates are those of the
+// eyes: clipped to the viewport and mapped through rotation, with the same
+// device fast paths, dithering and eyelid masks as the eyes.
+type Surface interface {
+	Size() (width, height int16)
+	SetPixel(x, y int16, c color.RGBA)
+	FillRect(x, y, width, height int16, c color.RGBA)
+	FillRoundRect(x, y, width, height, radius int16, c color.RGBA)
+	FillTriangle(x0, y0, x1, y1, x2, y2 int16, c color.RGBA)
+}
+
+// layer is a custom drawable at a z-level
+type layer struct {
+	id       int
+	z        int
+	drawable Drawable
+}
+
+// AddLayer inserts d at z-level z and returns its id for RemoveLayer.
+// Custom layers are drawn on every frame and may change at any time, so
+// frames are never skipped and the whole viewport is sent to the display
+// while any is present.
+func (r *RoboEyes) AddLayer(z int, d Drawable) int {
+	r.layerID++
+	i := len(r.layers)
+	for i > 0 && r.layers[i-1].z > z {
+		i--
+	}
+	r.layers = append(r.layers, layer{})
+	copy(r.layers[i+1:], r.layers[i:])
+	r.layers[i] = layer{r.layerID, z, d}
+	return r.layerID
+}
+
+// RemoveLayer removes the layer returned by AddLayer
+func (r *RoboEyes) RemoveLayer(id int) {
+	for i := range r.layers {
+		if r.layers[i].id == id {
+			r.layers = append(r.layers[:i], r.layers[i+1:]...)
+			r.frameValid = false
+			return
+		}
+	}
+}
+
+// drawLayers draws the custom layers from index i up to z-level below and
+// returns the index of the first layer not drawn
+func (r *RoboEyes) drawLayers(i, below int) int {
+	for ; i < len(r.layers) && r.layers[i].z < below; i++ {
+		r.layers[i].drawable.Draw((*surface)(r))
+	}
+	return i
+}
+
+// LeftEye returns the position and size of the left eye in the current frame
+func (r *RoboEyes) LeftEye() (x, y, width, height int16) {
+	return r.eyeLx, r.eyeLy, r.eyeLwidthCurrent, r.eyeLheightCurrent
+}
+
+// RightEye returns the position and size of the right eye in the current
+// frame, with a zero size in cyclops mode
+func (r *RoboEyes) RightEye() (x, y, width, height int16) {
+	return r.eyeRx, r.eyeRy, r.eyeRwidthCurrent, r.eyeRheightCurrent
+}
+
+// surface is the Surface view of the eyes given to drawables
+type surface RoboEyes
+
+func (s *surface) Size() (width, height int16) {
+	return s.screenWidth, s.screenHeight
+}
+
+func (s *surface) SetPixel(x, y int16, c color.RGBA) {
+	(*RoboEyes)(s).setPixel(x, y, c)
+}
+
+func (s *surface) FillRect(x, y, width, height int16, c color.RGBA) {
+	(*RoboEyes)(s).fillRect(x, y, width, height, c)
+}
+
+func (s *surface) FillRoundRect(x, y, width, height, radius int16, c color.RGBA) {
+	(*RoboEyes)(s).fillRoundRect(x, y, width, height, radius, c)
+}
+
+func (s *surface) FillTriangle(x0, y0, x1, y1, x2, y2 int16, c color.RGBA) {
+	(*RoboEyes)(s).fillTriangleAliased(x0, y0, x1, y1, x2, y2, c)
+}
@@ -0,0 +1,142 @@
+package roboeyestinygo
+
+import (
+	"image/color"
+	"testing"
+)
+
+var (
+	red   = color.RGBA{255, 0, 0, 255}
+	green = color.RGBA{0, 255, 0, 255}
+	blue  = color.RGBA{0, 0, 255, 255}
+)
+
+// fillLayer paints the whole surface
+func fillLayer(c color.RGBA) Drawable {
+	return DrawableFunc(func(s Surface) {
+		w, h := s.Size()
+		s.FillRect(0, 0, w, h, c)
+	})
+}
+
+func TestLayerOrder(t *testing.T) {
+	d := newTestDevice(128, 64)
+	r := renderMood(d, MoodDefault, func(r *RoboEyes) {
+		// Added out of order, drawn by z-level
+		r.AddLayer(LayerOverlay, DrawableFunc(func(s Surface) { s.FillRect(0, 0, 4, 4, blue) }))
+		r.AddLayer(LayerBackground+1, fillLayer(red))
+	})
+	x, y, w, h := r.LeftEye()
+	if c := d.at(x+w/2, y+h/2); c != white {
+		t.Errorf("eye %v", c)
+	}
+	if c := d.at(x-2, y+h/2); c != red {
+		t.Errorf("background layer %v", c)
+	}
+	if c := d.at(1, 1); c != blue {
+		t.Errorf("overlay %v", c)
+	}
+}
+
+func TestLayerBelowLids(t *testing.T) {
+	d := newTestDevice(128, 64)
+	r := renderMood(d, MoodTired, func(r *RoboEyes) {
+		r.AddLayer(LayerEyes+1, DrawableFunc(func(s Surface) {
+			x, y, w, h := r.LeftEye()
+			s.FillRect(x, y, w, h, green)
+		}))
+	})
+	// The tired eyelid covers the outer top corner of the layer
+	x, y, w, h := r.LeftEye()
+	if c := d.at(x+1, y+1); c == green {
+		t.Errorf("eyelid corner %v", c)
+	}
+	if c := d.at(x+w/2, y+h-2); c != green {
+		t.Errorf("layer %v", c)
+	}
+}
+
+func TestRemoveLayer(t *testing.T) {
+	a, b := newTestDevice(128, 64), newTestDevice(128, 64)
+	renderMood(a, MoodAngry, func(r *RoboEyes) {})
+	r := renderMood(b, MoodAngry, func(r *RoboEyes) {})
+	id := r.AddLayer(LayerOverlay, fillLayer(red))
+	step(r, 1)
+	if c := b.at(0, 0); c != red {
+		t.Fatalf("layer not drawn, %v", c)
+	}
+	r.RemoveLayer(id)
+	step(r, 1)
+	if n := diffPixels(a, b); n != 0 {
+		t.Errorf("%d pixels differ after removing the layer", n)
+	}
+}
+
+func TestLidMasksMatchPaintedLids(t *testing.T) {
+	for _, cyclops := range []bool{false, true} {
+		for _, moods := range [][]Mood{{MoodTired}, {MoodAngry}, {MoodHappy}, {MoodTired, MoodAngry}, {MoodHappy, MoodTired}} {
+			a, b := newTestDevice(128, 64), newTestDevice(128, 64)
+			ra, rb := newTestEyes(a, 50), newTestEyes(b, 50)
+			rb.SetLidMasks(true)
+			for _, r := range []*RoboEyes{ra, rb} {
+				r.SetCyclops(cyclops)
+				r.Open()
+			}
+			for _, mood := range moods {
+				ra.SetMood(mood)
+				rb.SetMood(mood)
+				for i := 0; i < 10; i++ {
+					step(ra, 1)
+					step(rb, 1)
+					lit := 0
+					for j := range a.pix {
+						// Masked lids leave the cleared buffer, painted ones bgColor
+						if (a.pix[j] == white) != (b.pix[j] == white) {
+							t.Fatalf("cyclops %v moods %v frame %d: pixel %d differs", cyclops, moods, i, j)
+						}
+						if a.pix[j] == white {
+							lit++
+						}
+					}
+					if lit == 0 {
+						t.Fatalf("cyclops %v moods %v: no eye drawn", cyclops, moods)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestLidMasksShowLayersBelow(t *testing.T) {
+	d := newTestDevice(128, 64)
+	r := renderMood(d, MoodAngry, func(r *RoboEyes) {
+		r.SetLidMasks(true)
+		r.AddLayer(LayerBackground+1, fillLayer(red))
+	})
+	// The inner top corner of the angry eye is cut out, not painted black
+	x, y, w, _ := r.LeftEye()
+	if c := d.at(x+w-2, y+1); c != red {
+		t.Errorf("masked eyelid %v", c)
+	}
+}
+
+func TestMaskRoundRectMatchesFill(t *testing.T) {
+	for width := int16(1); width < 30; width++ {
+		for height := int16(1); height < 30; height += 3 {
+			for radius := int16(0); radius < 16; radius++ {
+				d := newTestDevice(40, 40)
+				r := newTestEyes(d, 50)
+				r.fillRoundRect(5, 5, width, height, radius, white)
+				rr := maskRoundRect{5, 5, width, height, radius}
+				for y := int16(0); y < 40; y++ {
+					x0, x1, ok := rr.span(y)
+					for x := int16(0); x < 40; x++ {
+						if in := ok && x >= x0 && x <= x1; in != (d.at(x, y) == white) {
+							t.Fatalf("%dx%d radius %d: pixel %d,%d", width, height, radius, x, y)
+						}
+					}
+				}
+			}
+		}
+	}
+}
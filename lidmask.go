@@ -0,0 +1,212 @@
+package roboeyestinygo
+
+import "image/color"
+
+// SetLidMasks enables/disables eyelid masks. By default eyelids are painted
+// over the eyes with bgColor; with masks they are cut out of the eyes and of
+// the layers between LayerEyes and LayerLids instead, so whatever lies below
+// the eyes shows through. Mask edges are not anti-aliased.
+func (r *RoboEyes) SetLidMasks(active bool) {
+	r.lidMasks = active
+}
+
+// Most eyelid shapes in a frame: tired and angry triangles while switching
+// between both moods, and the two happy covers
+const (
+	maxMaskTriangles = 4
+	maxMaskRects     = 2
+)
+
+// lidMask holds the eyelid shapes of the frame. Rasterizers skip the pixels
+// they cover while masking is on.
+type lidMask struct {
+	triangles  [maxMaskTriangles]maskTriangle
+	rects      [maxMaskRects]maskRoundRect
+	nTriangles int
+	nRects     int
+}
+
+// rowSpans is the set of intervals of a row covered by eyelids, sorted by start
+type rowSpans struct {
+	spans [maxMaskTriangles + maxMaskRects][2]int16
+	n     int
+}
+
+// maskTriangle is an eyelid triangle with vertices sorted by y
+type maskTriangle struct {
+	x0, y0, x1, y1, x2, y2 int16
+}
+
+// maskRoundRect is a happy eyelid cover
+type maskRoundRect struct {
+	x, y, width, height, radius int16
+}
+
+// buildLidMask records the eyelids of the frame instead of drawing them
+func (r *RoboEyes) buildLidMask() {
+	r.mask = lidMask{}
+	r.recording = true
+	r.drawEyelids()
+	r.recording = false
+}
+
+// addTriangle records a triangle drawn by fillTriangle
+func (m *lidMask) addTriangle(x0, y0, x1, y1, x2, y2 int16) {
+	if m.nTriangles < len(m.triangles) {
+		x0, y0, x1, y1, x2, y2 = sortTriangle(x0, y0, x1, y1, x2, y2)
+		m.triangles[m.nTriangles] = maskTriangle{x0, y0, x1, y1, x2, y2}
+		m.nTriangles++
+	}
+}
+
+// addRoundRect records a rounded rectangle drawn by fillRoundRect
+func (m *lidMask) addRoundRect(x, y, width, height, radius int16) {
+	if m.nRects < len(m.rects) {
+		m.rects[m.nRects] = maskRoundRect{x, y, width, height, radius}
+		m.nRects++
+	}
+}
+
+// span returns the pixels of row y covered by the triangle, the same ones
+// fillTriangle fills: edges end on the pixels of edgeOffset and the flat
+// bottom row is left out
+func (t *maskTriangle) span(y int16) (x0, x1 int16, ok bool) {
+	if y < t.y0 || y > t.y2 || t.y0 == t.y2 || (y == t.y2 && t.y1 == t.y2) {
+		return 0, 0, false
+	}
+	x0 = t.x0 + edgeOffset(t.x2-t.x0, y-t.y0, t.y2-t.y0)
+	if y < t.y1 {
+		x1 = t.x0 + edgeOffset(t.x1-t.x0, y-t.y0, t.y1-t.y0)
+	} else {
+		x1 = t.x1 + edgeOffset(t.x2-t.x1, y-t.y1, t.y2-t.y1)
+	}
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	return x0, x1, true
+}
+
+// span returns the pixels of row y covered by the rounded rectangle, the
+// same ones fillRoundRect fills with its rectangles and quarter circles
+func (rr *maskRoundRect) span(y int16) (x0, x1 int16, ok bool) {
+	if rr.height <= 2 || rr.width <= 2 {
+		if y < rr.y || y >= rr.y+rr.height || rr.width <= 0 {
+			return 0, 0, false
+		}
+		return rr.x, rr.x + rr.width - 1, true
+	}
+	radius := max(min(rr.radius, rr.width/2, rr.height/2), 0)
+	left, right := rr.x+radius, rr.x+rr.width-radius-1
+
+	// Center and side rectangles
+	if y >= rr.y && y < rr.y+rr.height && left <= right {
+		x0, x1, ok = extendSpan(x0, x1, ok, left, right)
+	}
+	if y >= rr.y+radius && y < rr.y+rr.height-radius && radius > 0 {
+		x0, x1, ok = extendSpan(x0, x1, ok, rr.x, rr.x+rr.width-1)
+	}
+	if radius == 0 {
+		return x0, x1, ok
+	}
+
+	// Quarter circles, the top-right lines are one pixel shorter
+	if e, found := circleExtent(radius, rr.y+radius-y); found {
+		x0, x1, ok = extendSpan(x0, x1, ok, left-e, left+e)
+		if e > 0 {
+			x0, x1, ok = extendSpan(x0, x1, ok, right, right+e-1)
+		}
+	}
+	if e, found := circleExtent(radius, y-(rr.y+rr.height-radius-1)); found {
+		x0, x1, ok = extendSpan(x0, x1, ok, left-e, left+e)
+		x0, x1, ok = extendSpan(x0, x1, ok, right, right+e)
+	}
+	return x0, x1, ok
+}
+
+// extendSpan adds the pixels a to b to the span x0 to x1
+func extendSpan(x0, x1 int16, ok bool, a, b int16) (int16, int16, bool) {
+	if !ok {
+		return a, b, true
+	}
+	return min(x0, a), max(x1, b), true
+}
+
+// circleExtent returns how far the longest line fillCircle draws at distance
+// d from the center reaches sideways, walking the same Bresenham steps
+func circleExtent(radius, d int16) (extent int16, found bool) {
+	if d < 0 || d > radius {
+		return 0, false
+	}
+	f := 1 - radius
+	ddFx, ddFy := int16(1), -2*radius
+	x, y := int16(0), radius
+	for x <= y {
+		// Lines at distance x reach y pixels and the other way round
+		if x == d {
+			extent, found = max(extent, y), true
+		}
+		if y == d {
+			extent, found = max(extent, x), true
+		}
+		if f >= 0 {
+			y--
+			ddFy += 2
+			f += ddFy
+		}
+		x++
+		ddFx += 2
+		f += ddFx
+	}
+	return extent, found
+}
+
+// covers reports whether pixel (x, y) is under an eyelid
+func (m *lidMask) covers(x, y int16) bool {
+	for i := 0; i < m.nTriangles; i++ {
+		if x0, x1, ok := m.triangles[i].span(y); ok && x >= x0 && x <= x1 {
+			return true
+		}
+	}
+	for i := 0; i < m.nRects; i++ {
+		if x0, x1, ok := m.rects[i].span(y); ok && x >= x0 && x <= x1 {
+			return true
+		}
+	}
+	return false
+}
+
+// add inserts the interval x0 to x1 when ok
+func (rs *rowSpans) add(x0, x1 int16, ok bool) {
+	if !ok {
+		return
+	}
+	i := rs.n
+	for i > 0 && rs.spans[i-1][0] > x0 {
+		rs.spans[i] = rs.spans[i-1]
+		i--
+	}
+	rs.spans[i] = [2]int16{x0, x1}
+	rs.n++
+}
+
+// maskSpan draws the parts of pixels x0 to x1 of row y not under an eyelid
+func (r *RoboEyes) maskSpan(x0, x1, y int16, c color.RGBA) {
+	var covered rowSpans
+	for i := 0; i < r.mask.nTriangles; i++ {
+		covered.add(r.mask.triangles[i].span(y))
+	}
+	for i := 0; i < r.mask.nRects; i++ {
+		covered.add(r.mask.rects[i].span(y))
+	}
+
+	for _, span := range covered.spans[:covered.n] {
+		if span[0] > x0 {
+			r.drawSpan(x0, min(span[0]-1, x1), y, c)
+		}
+		x0 = max(x0, span[1]+1)
+		if x0 > x1 {
+			return
+		}
+	}
+	r.drawSpan(x0, x1, y, c)
+}
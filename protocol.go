@@ -0,0 +1,474 @@
+package roboeyestinygo
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrorCode identifies why a protocol command was rejected
+type ErrorCode byte
+
+const (
+	CodeOK              ErrorCode = iota
+	CodeUnknownCommand            // Command verb not recognized
+	CodeMissingArgument           // Too few arguments for the command
+	CodeInvalidArgument           // Argument could not be parsed or is out of range
+	CodeBadFrame                  // Binary frame failed length or CRC validation
+)
+
+// String returns the short name sent in error replies
+func (e ErrorCode) String() string {
+	switch e {
+	case CodeOK:
+		return "ok"
+	case CodeUnknownCommand:
+		return "unknown command"
+	case CodeMissingArgument:
+		return "missing argument"
+	case CodeInvalidArgument:
+		return "invalid argument"
+	case CodeBadFrame:
+		return "bad frame"
+	default:
+		return "unknown error"
+	}
+}
+
+// Mood and direction names used by the text protocol
+var (
+	moodNames      = [...]string{"default", "tired", "angry", "happy"}
+	directionNames = [...]string{"c", "n", "ne", "e", "se", "s", "sw", "w", "nw"}
+)
+
+// TextProtocol is a line-based command interpreter driving a RoboEyes instance.
+// Each request is a single line made of a verb and space separated arguments,
+// each reply is a single line: "OK", "ERR <code> <message>" or "STATE ..."
+//
+//	MOOD <default|tired|angry|happy>
+//	DIR <c|n|ne|e|se|s|sw|w|nw>
+//	OPEN|CLOSE|BLINK [left|right|both]
+//	LAUGH | CONFUSED
+//	WINK [left|right] [ms] | SQUINT [ms] | EYEROLL [ms]
+//	NOD|SHAKE <count> <amplitude> <period ms>
+//	SLEEP | WAKE | STARTLE <intensity 0-255>
+//	AUTOBLINK <0|1> [interval variation] (seconds, 0-65535)
+//	IDLE <0|1> [interval variation] (seconds, 0-65535)
+//	CURIOUS <0|1> | CYCLOPS <0|1>
+//	HFLICKER <0|1> [amplitude] | VFLICKER <0|1> [amplitude]
+//	SIZE <left> <right> | RADIUS <left> <right> | SPACE <pixels> | FPS <1-1000>
+//	PING | STATE
+type TextProtocol struct {
+	eyes   *RoboEyes
+	locker sync.Locker
+}
+
+// NewTextProtocol creates a text protocol bound to the given eyes
+func NewTextProtocol(eyes *RoboEyes) *TextProtocol {
+	return &TextProtocol{eyes: eyes}
+}
+
+// SetLocker makes every command run while holding l, e.g. a Controller
+func (p *TextProtocol) SetLocker(l sync.Locker) {
+	p.locker = l
+}
+
+// Serve reads commands line by line from rd and writes one reply per command to w.
+// It returns when rd is exhausted or on the first read/write error.
+func (p *TextProtocol) Serve(rd io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(rd)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if _, err := io.WriteString(w, p.Execute(line)+"\n"); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Execute runs a single command line and returns the reply without line terminator
+func (p *TextProtocol) Execute(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return errorReply(CodeMissingArgument)
+	}
+	if p.locker != nil {
+		p.locker.Lock()
+		defer p.locker.Unlock()
+	}
+	if code := p.execute(strings.ToUpper(fields[0]), fields[1:]); code != CodeOK {
+		return errorReply(code)
+	}
+	if strings.EqualFold(fields[0], "STATE") {
+		return p.stateReply()
+	}
+	return "OK"
+}
+
+func (p *TextProtocol) execute(verb string, args []string) ErrorCode {
+	r := p.eyes
+	switch verb {
+	case "PING", "STATE":
+		return CodeOK
+	case "MOOD":
+		if len(args) < 1 {
+			return CodeMissingArgument
+		}
+		idx := lookupName(moodNames[:], args[0])
+		if idx < 0 {
+			return CodeInvalidArgument
+		}
+		r.SetMood(Mood(idx))
+	case "DIR":
+		if len(args) < 1 {
+			return CodeMissingArgument
+		}
+		idx := lookupName(directionNames[:], args[0])
+		if idx < 0 {
+			return CodeInvalidArgument
+		}
+		r.SetDirection(Direction(idx))
+	case "OPEN", "CLOSE", "BLINK":
+		left, right, code := parseEyes(args)
+		if code != CodeOK {
+			return code
+		}
+		switch verb {
+		case "OPEN":
+			r.OpenEyes(left, right)
+		case "CLOSE":
+			r.CloseEyes(left, right)
+		default:
+			r.BlinkEyes(left, right)
+		}
+	case "LAUGH":
+		r.AnimLaugh()
+	case "CONFUSED":
+		r.AnimConfused()
+	case "WINK":
+		left := true
+		if len(args) > 0 {
+			l, rt, code := parseEyes(args[:1])
+			if code != CodeOK {
+				return code
+			}
+			if l == rt {
+				return CodeInvalidArgument
+			}
+			left = l
+		}
+		duration, code := parseDuration(args, 1, r.winkAnimationDuration)
+		if code != CodeOK {
+			return code
+		}
+		r.AnimWinkWithDuration(left, duration)
+	case "SQUINT":
+		duration, code := parseDuration(args, 0, r.squintAnimationDuration)
+		if code != CodeOK {
+			return code
+		}
+		r.AnimSquint(duration)
+	case "EYEROLL":
+		duration, code := parseDuration(args, 0, r.eyeRollAnimationDuration)
+		if code != CodeOK {
+			return code
+		}
+		r.AnimEyeRollWithDuration(duration)
+	case "NOD", "SHAKE":
+		if len(args) < 3 {
+			return CodeMissingArgument
+		}
+		count, code := parseUint(args[0])
+		if code != CodeOK {
+			return code
+		}
+		amplitude, code := parseInt16(args[1])
+		if code != CodeOK {
+			return code
+		}
+		period, code := parseUint(args[2])
+		if code != CodeOK {
+			return code
+		}
+		if count == 0 || count > 0xFFFF || period == 0 {
+			return CodeInvalidArgument
+		}
+		if verb == "NOD" {
+			r.AnimNod(uint16(count), amplitude, period)
+		} else {
+			r.AnimShake(uint16(count), amplitude, period)
+		}
+	case "SLEEP":
+		r.Sleep()
+	case "WAKE":
+		r.Wake()
+	case "STARTLE":
+		if len(args) < 1 {
+			return CodeMissingArgument
+		}
+		intensity, code := parseUint(args[0])
+		if code != CodeOK {
+			return code
+		}
+		if intensity > 255 {
+			return CodeInvalidArgument
+		}
+		r.AnimStartle(uint8(intensity))
+	case "AUTOBLINK", "IDLE":
+		if len(args) < 1 {
+			return CodeMissingArgument
+		}
+		active, code := parseBool(args[0])
+		if code != CodeOK {
+			return code
+		}
+		set, setWithInterval := r.SetAutoBlinker, r.SetAutoBlinkerWithInterval
+		if verb == "IDLE" {
+			set, setWithInterval = r.SetIdleMode, r.SetIdleModeWithInterval
+		}
+		if len(args) < 2 {
+			set(active)
+			return CodeOK
+		}
+		if len(args) < 3 {
+			return CodeMissingArgument
+		}
+		interval, code := parseSeconds(args[1])
+		if code != CodeOK {
+			return code
+		}
+		variation, code := parseSeconds(args[2])
+		if code != CodeOK {
+			return code
+		}
+		setWithInterval(active, interval, variation)
+	case "CURIOUS", "CYCLOPS":
+		if len(args) < 1 {
+			return CodeMissingArgument
+		}
+		active, code := parseBool(args[0])
+		if code != CodeOK {
+			return code
+		}
+		if verb == "CURIOUS" {
+			r.SetCuriosity(active)
+		} else {
+			r.SetCyclops(active)
+		}
+	case "HFLICKER", "VFLICKER":
+		if len(args) < 1 {
+			return CodeMissingArgument
+		}
+		active, code := parseBool(args[0])
+		if code != CodeOK {
+			return code
+		}
+		amplitude := r.hFlickerAmplitude
+		if verb == "VFLICKER" {
+			amplitude = r.vFlickerAmplitude
+		}
+		if len(args) > 1 {
+			if amplitude, code = parseInt16(args[1]); code != CodeOK {
+				return code
+			}
+		}
+		if verb == "HFLICKER" {
+			r.SetHFlicker(active, amplitude)
+		} else {
+			r.SetVFlicker(active, amplitude)
+		}
+	case "SIZE", "RADIUS":
+		if len(args) < 2 {
+			return CodeMissingArgument
+		}
+		left, code := parseInt16(args[0])
+		if code != CodeOK {
+			return code
+		}
+		right, code := parseInt16(args[1])
+		if code != CodeOK {
+			return code
+		}
+		if verb == "SIZE" {
+			if !r.fitsScreen(left, right, r.spaceBetweenDefault) {
+				return CodeInvalidArgument
+			}
+			r.SetSize(left, right)
+			return CodeOK
+		}
+		if left < 0 || left > 255 || right < 0 || right > 255 {
+			return CodeInvalidArgument
+		}
+		r.SetBorderRadius(byte(left), byte(right))
+	case "SPACE":
+		if len(args) < 1 {
+			return CodeMissingArgument
+		}
+		space, code := parseInt16(args[0])
+		if code != CodeOK {
+			return code
+		}
+		if !r.fitsScreen(r.eyeLwidthDefault, r.eyeRwidthDefault, space) {
+			return CodeInvalidArgument
+		}
+		r.SetSpaceBetween(space)
+	case "FPS":
+		if len(args) < 1 {
+			return CodeMissingArgument
+		}
+		fps, code := parseUint(args[0])
+		if code != CodeOK {
+			return code
+		}
+		if fps == 0 || fps > maxFrameRate {
+			return CodeInvalidArgument
+		}
+		r.SetFramerate(fps)
+	default:
+		return CodeUnknownCommand
+	}
+	return CodeOK
+}
+
+// stateReply formats the current eyes state as a single STATE line
+func (p *TextProtocol) stateReply() string {
+	s := p.eyes.status()
+	var b strings.Builder
+	b.WriteString("STATE mood=")
+	b.WriteString(moodNames[s.Mood])
+	b.WriteString(" dir=")
+	b.WriteString(directionNames[s.Direction])
+	b.WriteString(" left=")
+	b.WriteString(openName(s.LeftOpen))
+	b.WriteString(" right=")
+	b.WriteString(openName(s.RightOpen))
+	writeFlag(&b, "autoblink", s.AutoBlinker)
+	writeFlag(&b, "idle", s.Idle)
+	writeFlag(&b, "curious", s.Curious)
+	writeFlag(&b, "cyclops", s.Cyclops)
+	writeFlag(&b, "laugh", s.Laughing)
+	writeFlag(&b, "confused", s.Confused)
+	writeFlag(&b, "sleep", s.Sleeping)
+	return b.String()
+}
+
+// status collects the current eyes state reported by the protocols
+func (r *RoboEyes) status() Status {
+	s := r.Snapshot()
+	return Status{
+		Mood:        s.Mood,
+		Direction:   s.Direction,
+		LeftOpen:    s.LeftOpen,
+		RightOpen:   s.RightOpen,
+		AutoBlinker: s.AutoBlinker,
+		Idle:        s.Idle,
+		Curious:     s.Curious,
+		Cyclops:     s.Cyclops,
+		Laughing:    s.Laughing,
+		Confused:    s.Confused,
+		Sleeping:    r.Sleeping(),
+	}
+}
+
+// fitsScreen reports whether eyes of the given widths and spacing fit side by
+// side on the screen, wider eyes would leave the gaze no room to move
+func (r *RoboEyes) fitsScreen(left, right, space int16) bool {
+	return left > 0 && right > 0 && int32(left)+int32(space)+int32(right) <= int32(r.screenWidth)
+}
+
+func errorReply(code ErrorCode) string {
+	return "ERR " + strconv.Itoa(int(code)) + " " + code.String()
+}
+
+func writeFlag(b *strings.Builder, name string, active bool) {
+	b.WriteString(" ")
+	b.WriteString(name)
+	if active {
+		b.WriteString("=1")
+	} else {
+		b.WriteString("=0")
+	}
+}
+
+func openName(open bool) string {
+	if open {
+		return "open"
+	}
+	return "closed"
+}
+
+// lookupName returns the index of name in names (case insensitive) or -1
+func lookupName(names []string, name string) int {
+	for i, n := range names {
+		if strings.EqualFold(n, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseEyes decodes the optional eye selector of OPEN, CLOSE and BLINK
+func parseEyes(args []string) (left, right bool, code ErrorCode) {
+	if len(args) == 0 {
+		return true, true, CodeOK
+	}
+	switch strings.ToLower(args[0]) {
+	case "left", "l":
+		return true, false, CodeOK
+	case "right", "r":
+		return false, true, CodeOK
+	case "both", "lr":
+		return true, true, CodeOK
+	}
+	return false, false, CodeInvalidArgument
+}
+
+func parseBool(s string) (bool, ErrorCode) {
+	switch strings.ToLower(s) {
+	case "1", "on", "true":
+		return true, CodeOK
+	case "0", "off", "false":
+		return false, CodeOK
+	}
+	return false, CodeInvalidArgument
+}
+
+func parseUint(s string) (uint32, ErrorCode) {
+	v, code := strconv.ParseUint(s, 10, 32)
+	if code != nil {
+		return 0, CodeInvalidArgument
+	}
+	return uint32(v), CodeOK
+}
+
+// parseSeconds decodes an interval in seconds, up to 65535 like the u16
+// fields of the binary protocol so that it fits in milliseconds
+func parseSeconds(s string) (uint32, ErrorCode) {
+	v, code := strconv.ParseUint(s, 10, 16)
+	if code != nil {
+		return 0, CodeInvalidArgument
+	}
+	return uint32(v), CodeOK
+}
+
+// parseDuration decodes the optional duration in milliseconds at args[i],
+// def when it is missing
+func parseDuration(args []string, i int, def uint32) (uint32, ErrorCode) {
+	if len(args) <= i {
+		return def, CodeOK
+	}
+	return parseUint(args[i])
+}
+
+func parseInt16(s string) (int16, ErrorCode) {
+	v, code := strconv.ParseInt(s, 10, 16)
+	if code != nil {
+		return 0, CodeInvalidArgument
+	}
+	return int16(v), CodeOK
+}
@@ -0,0 +1,154 @@
+package roboeyestinygo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTextProtocolServe(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	p := NewTextProtocol(r)
+	in := bytes.NewBufferString("MOOD happy\n\ndir NE\nFOO\nMOOD\nMOOD sad\nIDLE 1 2 0\nblink left\nSIZE x 2\nPING\n")
+	var out bytes.Buffer
+	if err := p.Serve(in, &out); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"OK",
+		"OK",
+		"ERR 1 unknown command",
+		"ERR 2 missing argument",
+		"ERR 3 invalid argument",
+		"OK",
+		"OK",
+		"ERR 3 invalid argument",
+		"OK",
+	}, "\n") + "\n"
+	if out.String() != want {
+		t.Fatalf("replies:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if r.mood != MoodHappy || r.direction != DirNE || !r.idle {
+		t.Fatalf("mood %d direction %d idle %v", r.mood, r.direction, r.idle)
+	}
+}
+
+func TestTextProtocolState(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	p := NewTextProtocol(r)
+	for _, line := range []string{"MOOD angry", "DIR sw", "OPEN", "CLOSE right", "AUTOBLINK on", "CYCLOPS 1"} {
+		if reply := p.Execute(line); reply != "OK" {
+			t.Fatalf("%s: %s", line, reply)
+		}
+	}
+	want := "STATE mood=angry dir=sw left=open right=closed autoblink=1 idle=0 curious=0 cyclops=1 laugh=0 confused=0 sleep=0"
+	if reply := p.Execute("state"); reply != want {
+		t.Fatalf("got  %s\nwant %s", reply, want)
+	}
+}
+
+func TestTextProtocolArguments(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	p := NewTextProtocol(r)
+	for _, c := range []struct{ line, reply string }{
+		{"AUTOBLINK 1 3", "ERR 2 missing argument"},
+		{"AUTOBLINK maybe", "ERR 3 invalid argument"},
+		{"AUTOBLINK 1 1 3000000", "ERR 3 invalid argument"},
+		{"IDLE 1 5000000 0", "ERR 3 invalid argument"},
+		{"IDLE 1 65535 65535", "OK"},
+		{"HFLICKER 1 4", "OK"},
+		{"VFLICKER 1 x", "ERR 3 invalid argument"},
+		{"RADIUS 4 300", "ERR 3 invalid argument"},
+		{"FPS 0", "ERR 3 invalid argument"},
+		{"FPS 1001", "ERR 3 invalid argument"},
+		{"FPS 30", "OK"},
+		{"OPEN middle", "ERR 3 invalid argument"},
+	} {
+		if reply := p.Execute(c.line); reply != c.reply {
+			t.Errorf("%s: got %q, want %q", c.line, reply, c.reply)
+		}
+	}
+	if !r.hFlicker || r.hFlickerAmplitude != 4 {
+		t.Errorf("hflicker %v %d", r.hFlicker, r.hFlickerAmplitude)
+	}
+	if r.idleInterval != 65535000 || r.idleIntervalVariation != 65535000 {
+		t.Errorf("idle interval %d variation %d", r.idleInterval, r.idleIntervalVariation)
+	}
+}
+
+func TestTextProtocolRejectsEyesWiderThanScreen(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	p := NewTextProtocol(r)
+	for _, c := range []struct{ line, reply string }{
+		{"SIZE 80 80", "ERR 3 invalid argument"},
+		{"SIZE 0 20", "ERR 3 invalid argument"},
+		{"SPACE 100", "ERR 3 invalid argument"},
+		{"SIZE 50 60", "OK"},
+		{"IDLE 1 0 0", "OK"},
+	} {
+		if reply := p.Execute(c.line); reply != c.reply {
+			t.Errorf("%s: got %q, want %q", c.line, reply, c.reply)
+		}
+	}
+	// Idle mode picks random positions within the screen constraint
+	step(r, 50)
+	if r.GetScreenConstraintX() < 0 {
+		t.Fatalf("screen constraint %d", r.GetScreenConstraintX())
+	}
+}
+
+func TestTextProtocolGestures(t *testing.T) {
+	for _, c := range []struct {
+		line    string
+		reply   string
+		playing func(r *RoboEyes) bool
+	}{
+		{"WINK", "OK", func(r *RoboEyes) bool { return r.wink && r.winkLeft }},
+		{"WINK right 600", "OK", func(r *RoboEyes) bool { return r.wink && !r.winkLeft && r.winkAnimationDuration == 600 }},
+		{"WINK both", "ERR 3 invalid argument", nil},
+		{"WINK left x", "ERR 3 invalid argument", nil},
+		{"SQUINT", "OK", func(r *RoboEyes) bool { return r.squint }},
+		{"SQUINT 300", "OK", func(r *RoboEyes) bool { return r.squint && r.squintAnimationDuration == 300 }},
+		{"EYEROLL 800", "OK", func(r *RoboEyes) bool { return r.eyeRoll && r.eyeRollAnimationDuration == 800 }},
+		{"NOD 2 6 200", "OK", func(r *RoboEyes) bool { return r.nod.active && r.nod.count == 2 && r.nod.amplitude == 6 }},
+		{"SHAKE 1 -10 400", "OK", func(r *RoboEyes) bool { return r.shake.active && r.shake.amplitude == -10 && r.shake.period == 400 }},
+		{"NOD 2 6", "ERR 2 missing argument", nil},
+		{"SHAKE 0 6 200", "ERR 3 invalid argument", nil},
+		{"NOD 2 6 0", "ERR 3 invalid argument", nil},
+		{"STARTLE 200", "OK", func(r *RoboEyes) bool { return r.startle && r.startleIntensity == 200 }},
+		{"STARTLE", "ERR 2 missing argument", nil},
+		{"STARTLE 256", "ERR 3 invalid argument", nil},
+	} {
+		r := newTestEyes(newTestDevice(128, 64), 50)
+		r.Open()
+		p := NewTextProtocol(r)
+		if reply := p.Execute(c.line); reply != c.reply {
+			t.Errorf("%s: got %q, want %q", c.line, reply, c.reply)
+			continue
+		}
+		step(r, 1)
+		if c.playing != nil && !c.playing(r) {
+			t.Errorf("%s: animation not playing", c.line)
+		}
+	}
+}
+
+func TestTextProtocolSleep(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	r.Open()
+	p := NewTextProtocol(r)
+	if reply := p.Execute("SLEEP"); reply != "OK" {
+		t.Fatal(reply)
+	}
+	step(r, 200)
+	if reply := p.Execute("STATE"); !strings.Contains(reply, " left=closed right=closed") || !strings.HasSuffix(reply, " sleep=1") {
+		t.Fatalf("asleep: %s", reply)
+	}
+	if reply := p.Execute("WAKE"); reply != "OK" {
+		t.Fatal(reply)
+	}
+	step(r, 100)
+	if reply := p.Execute("STATE"); !strings.Contains(reply, " left=open right=open") || !strings.HasSuffix(reply, " sleep=0") {
+		t.Fatalf("awake: %s", reply)
+	}
+}
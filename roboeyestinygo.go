@@ -2,6 +2,8 @@ package roboeyestinygo
 
 import (
 	"image/color"
+	"math"
+	"math/bits"
 	"math/rand"
 	"time"
 )
@@ -14,6 +16,24 @@ type DeviceInterface interface {
 	Size() (width, height int16)       // Get display dimensions
 }
 
+// RectangleFiller is an optional DeviceInterface extension for devices that
+// can fill a rectangle in a single bus transaction (e.g. ST7789, ILI9341)
+type RectangleFiller interface {
+	FillRectangle(x, y, width, height int16, c color.RGBA) error
+}
+
+// HLineDrawer is an optional DeviceInterface extension for devices that can
+// draw a horizontal span from x0 to x1 (inclusive) in a single operation
+type HLineDrawer interface {
+	DrawFastHLine(x0, x1, y int16, c color.RGBA)
+}
+
+// PartialDisplayer is an optional DeviceInterface extension for devices that
+// can transfer only a region of their buffer to the panel
+type PartialDisplayer interface {
+	DisplayRegion(x, y, width, height int16) error
+}
+
 // Mood constants
 type Mood byte
 
@@ -41,22 +61,82 @@ const (
 
 // RoboEyes represents the robot eyes controller
 type RoboEyes struct {
-	device    DeviceInterface
-	startTime time.Time
-	eyesColor color.RGBA
-	bgColor   color.RGBA
-
-	// Display parameters
+	device     DeviceInterface
+	rectFill   RectangleFiller // optional fast path, nil if unsupported
+	hLine      HLineDrawer     // optional fast path, nil if unsupported
+	monochrome bool            // device declared itself 1-bit
+	dither     Dither
+	startTime  time.Time
+	eyesColor  color.RGBA
+	bgColor    color.RGBA
+
+	// Eye color palette and transitions
+	baseColor               color.RGBA // eye color set with SetColors, shown while the palette is off
+	moodColors              [4]color.RGBA
+	moodColorsActive        bool
+	colorTransition         bool
+	colorFrom               color.RGBA
+	colorTo                 color.RGBA
+	colorTransitionTimer    uint32
+	colorTransitionDuration uint32
+	colorSpace              ColorSpace
+
+	// Eye fill styles, shaders of the current frame and the shader used by
+	// the rasterizers, nil for flat fills
+	fillStyles [2]FillStyle
+	shaders    [2]eyeShader
+	shading    *eyeShader
+
+	// Picture behind the eyes, nil for bgColor, and whether the rasterizers
+	// paint it instead of the color they are given
+	background Background
+	backdrop   bool
+
+	// Custom layers sorted by z-level, and eyelid masks: the eyelids of the
+	// frame, whether rasterizers skip them and whether they are recorded
+	// instead of drawn
+	layers    []layer
+	layerID   int
+	lidMasks  bool
+	mask      lidMask
+	masking   bool
+	recording bool
+
+	// Display parameters, screen dimensions are those of the viewport on the
+	// panel after rotation
 	screenWidth   int16
 	screenHeight  int16
+	viewport      region // requested viewport, empty for the whole display
+	offsetX       int16  // viewport position on the display
+	offsetY       int16
+	fullScreen    bool // viewport covers the whole display
+	panelWidth    int16
+	panelHeight   int16
+	rotation      Rotation
+	mirrorX       bool
+	mirrorY       bool
+	transformed   bool // rotated or mirrored, coordinates must be mapped
+	autoScale     bool
+	dualWidth     int16 // width of each display in dual mode, 0 for a single display
+	scale         int32 // layout scale relative to the 128x64 reference, 256 is 1:1
 	frameInterval uint32
+	frameRate     uint32 // frames per second requested with SetFramerate
 	fpsTimer      uint32
+	antialias     bool
+
+	// Dirty tracking: last rendered frame and the region that changed
+	lastFrame   frameState
+	frameValid  bool
+	fullRefresh bool // next display must send the whole screen
+	dirty       region
 
 	// Eye states
-	tired   bool
-	angry   bool
-	happy   bool
-	curious bool
+	mood      Mood
+	direction Direction
+	tired     bool
+	angry     bool
+	happy     bool
+	curious   bool
 
 	cyclops   bool
 	eyeL_open bool
@@ -134,6 +214,58 @@ type RoboEyes struct {
 	laughAnimationTimer       uint32
 	laughAnimationDuration    uint32
 	laughToggle               bool
+
+	// Wink, squint and eye roll animations
+	wink                     bool
+	winkLeft                 bool
+	winkAnimationTimer       uint32
+	winkAnimationDuration    uint32
+	winkToggle               bool
+	squint                   bool
+	squintAnimationTimer     uint32
+	squintAnimationDuration  uint32
+	squintToggle             bool
+	eyeRoll                  bool
+	eyeRollAnimationTimer    uint32
+	eyeRollAnimationDuration uint32
+	eyeRollToggle            bool
+	eyeRollFromX             int16
+	eyeRollFromY             int16
+
+	// Nod and shake animations, moving the eye pair along a sine wave
+	nod   sway
+	shake sway
+
+	// Sleep state machine
+	sleep           sleepState
+	sleepTimer      uint32 // start of the current sleep state
+	sleepTimeout    uint32 // inactivity before falling asleep, 0 never
+	lastInteraction uint32
+	drowsy          bool  // tired eyelids whatever the mood
+	zzz             bool  // draw Zzz while asleep
+	zzzStep         int16 // Zzz animation step of the frame, 0 without Zzz
+
+	// Startle reaction, restoring mood and gaze when it is over
+	startle            bool
+	startleTimer       uint32
+	startleIntensity   uint8
+	startleBlinked     uint32
+	startleCooldown    uint32
+	startleCooldownEnd uint32
+	startleMood        Mood
+	startleDirection   Direction
+	startleX           int16
+	startleY           int16
+
+	// Event handler and the blink reported by the last BlinkStart event
+	onEvent    func(Event)
+	blinking   bool
+	blinkLeft  bool
+	blinkRight bool
+
+	// Animation scheduler: playing and waiting animations per channel
+	channels   [channelCount]animationChannel
+	priorities [len(animationNames)]uint8
 }
 
 func (r *RoboEyes) setDefault(screenWidth, screenHeight int16) {
@@ -141,14 +273,47 @@ func (r *RoboEyes) setDefault(screenWidth, screenHeight int16) {
 
 	r.eyesColor = color.RGBA{255, 255, 255, 255}
 	r.bgColor = color.RGBA{0, 0, 0, 255}
+	r.baseColor = r.eyesColor
+	r.moodColors = defaultMoodColors // eye color per mood, used when mood colors are active
+	r.moodColorsActive = false
+	r.colorTransition = false
+	r.colorTo = r.eyesColor
+	r.colorTransitionDuration = 400 // fade duration of mood colors in milliseconds
+	r.colorSpace = ColorSpaceRGB
+	r.fillStyles = [2]FillStyle{} // flat eyesColor fill for both eyes
+	r.layers = nil                // no custom layers
+	r.lidMasks = false            // eyelids are painted over the eyes with bgColor
+	r.background = nil            // flat bgColor behind the eyes
 
 	// For general setup - screen size and max. frame rate
 	r.screenWidth = screenWidth   // OLED display width, in pixels
 	r.screenHeight = screenHeight // OLED display height, in pixels
+	r.frameRate = 50              // default value for 50 frames per second
 	r.frameInterval = 20          // default value for 50 frames per second (1000/50 = 20 milliseconds)
 	r.fpsTimer = 0                // for timing the frames per second
+	r.antialias = false           // blend eye and eyelid edges, for color and grayscale displays
+	r.dither = DitherNone         // how intermediate intensities are shown on monochrome displays
+
+	// Display as mounted, neither rotated nor mirrored, eyes use all of it
+	r.panelWidth = screenWidth
+	r.panelHeight = screenHeight
+	r.viewport = region{}
+	r.offsetX = 0
+	r.offsetY = 0
+	r.fullScreen = true
+	r.rotation = Rotate0
+	r.mirrorX = false
+	r.mirrorY = false
+	r.transformed = false
+
+	// Geometry in pixels of the 128x64 reference design
+	r.autoScale = false
+	r.scale = scaleUnit
+	r.dualWidth = 0 // single display, BeginDual sets it afterwards
 
 	// For controlling mood types and expressions
+	r.mood = MoodDefault
+	r.direction = DirCenter
 	r.tired = false
 	r.angry = false
 	r.happy = false
@@ -257,11 +422,66 @@ func (r *RoboEyes) setDefault(screenWidth, screenHeight int16) {
 	r.laughAnimationDuration = 500
 	r.laughToggle = true
 
+	// Animation - wink: one eye closed for a moment, head slightly tilted
+	r.wink = false
+	r.winkLeft = false
+	r.winkAnimationTimer = 0
+	r.winkAnimationDuration = 400
+	r.winkToggle = true
+
+	// Animation - squint: both eyes narrowed for a moment
+	r.squint = false
+	r.squintAnimationTimer = 0
+	r.squintAnimationDuration = 1000
+	r.squintToggle = true
+
+	// Animation - eye roll: gaze traveling along an arc over the top
+	r.eyeRoll = false
+	r.eyeRollAnimationTimer = 0
+	r.eyeRollAnimationDuration = 1000
+	r.eyeRollToggle = true
+
+	// Animation - nod and shake: eyes moving up and down or left and right
+	r.nod = sway{}
+	r.shake = sway{}
+
+	// Animation - sleep: tired eyes falling asleep after some inactivity
+	r.sleep = sleepAwake
+	r.sleepTimer = 0
+	r.sleepTimeout = 0 // never fall asleep by itself
+	r.lastInteraction = 0
+	r.drowsy = false
+	r.zzz = false
+	r.zzzStep = 0
+
+	// Animation - startle: wide eyes shivering and blinking on external events
+	r.startle = false
+	r.startleTimer = 0
+	r.startleIntensity = 0
+	r.startleBlinked = 0
+	r.startleCooldown = 2000 // startles are ignored this long after the last one ended
+	r.startleCooldownEnd = 0
+
+	// Events - keep the handler set before Begin
+	r.blinking = false
+	r.blinkLeft = false
+	r.blinkRight = false
+
+	// Animation scheduler - one animation per channel, others wait their turn
+	r.channels = [channelCount]animationChannel{}
+	for i := range r.priorities {
+		r.priorities[i] = defaultAnimationPriority
+	}
+
 }
 
 // Begin initializes the RoboEyes controller
 func (r *RoboEyes) Begin(device DeviceInterface, width, height int16, frameRate uint32) {
 	r.device = device
+	r.rectFill, _ = device.(RectangleFiller)
+	r.hLine, _ = device.(HLineDrawer)
+	r.monochrome = monochrome(device)
+	r.frameValid = false
 	r.setDefault(width, height)
 	r.SetFramerate(frameRate)
 }
@@ -283,15 +503,25 @@ func (r *RoboEyes) Update() {
 	}
 }
 
-// SetFramerate sets the maximum frame rate
+// Highest frame rate accepted by SetFramerate, one frame per millisecond
+const maxFrameRate = 1000
+
+// SetFramerate sets the maximum frame rate, from 1 to 1000 frames per
+// second; other values are ignored
 func (r *RoboEyes) SetFramerate(fps uint32) {
-	if fps > 0 {
+	if fps > 0 && fps <= maxFrameRate {
+		r.frameRate = fps
 		r.frameInterval = 1000 / fps
 	}
 }
 
 // SetSize sets default eye dimensions
 func (r *RoboEyes) SetSize(left, right int16) {
+	r.interact()
+	r.setSize(left, right)
+}
+
+func (r *RoboEyes) setSize(left, right int16) {
 	r.eyeLwidthNext = left
 	r.eyeRwidthNext = right
 	r.eyeLwidthDefault = left
@@ -300,6 +530,11 @@ func (r *RoboEyes) SetSize(left, right int16) {
 
 // SetBorderRadius sets eye corner rounding
 func (r *RoboEyes) SetBorderRadius(left, right byte) {
+	r.interact()
+	r.setBorderRadius(left, right)
+}
+
+func (r *RoboEyes) setBorderRadius(left, right byte) {
 	r.eyeLborderRadiusNext = left
 	r.eyeRborderRadiusNext = right
 	r.eyeLborderRadiusDefault = left
@@ -308,13 +543,26 @@ func (r *RoboEyes) SetBorderRadius(left, right byte) {
 
 // SetSpaceBetween sets distance between eyes
 func (r *RoboEyes) SetSpaceBetween(space int16) {
+	r.interact()
+	r.setSpaceBetween(space)
+}
+
+func (r *RoboEyes) setSpaceBetween(space int16) {
 	r.spaceBetweenNext = space
 	r.spaceBetweenDefault = space
 }
 
 // SetMood configures eye expression
 func (r *RoboEyes) SetMood(mood Mood) {
+	r.interact()
+	r.setMood(mood)
+}
+
+// setMood configures eye expression, for animations
+func (r *RoboEyes) setMood(mood Mood) {
+	previous := r.mood
 	r.tired, r.angry, r.happy = false, false, false
+	r.mood = mood
 	switch mood {
 	case MoodTired:
 		r.tired = true
@@ -323,14 +571,32 @@ func (r *RoboEyes) SetMood(mood Mood) {
 	case MoodHappy:
 		r.happy = true
 	default:
-
+		r.mood = MoodDefault
 	}
+	if r.moodColorsActive && r.colorTo != r.moodColors[r.mood] {
+		r.startColorTransition(r.moodColors[r.mood])
+	}
+	if r.mood != previous {
+		r.emit(Event{Type: EventMoodChanged, Mood: r.mood})
+	}
+}
+
+// GetMood returns the current eye expression
+func (r *RoboEyes) GetMood() Mood {
+	return r.mood
 }
 
 // SetDirection moves eyes to predefined location
 func (r *RoboEyes) SetDirection(direction Direction) {
+	r.interact()
+	r.setDirection(direction)
+}
+
+// setDirection moves eyes to predefined location, when the layout changes
+func (r *RoboEyes) setDirection(direction Direction) {
 	maxX := r.GetScreenConstraintX()
 	maxY := r.GetScreenConstraintY()
+	r.direction = direction
 	switch direction {
 	case DirN:
 		// North, top center
@@ -366,9 +632,16 @@ func (r *RoboEyes) SetDirection(direction Direction) {
 		r.eyeLyNext = 0
 	default:
 		// Middle center
+		r.direction = DirCenter
 		r.eyeLxNext = maxX / 2
 		r.eyeLyNext = maxY / 2
 	}
+	r.gazeTargetChanged()
+}
+
+// GetDirection returns the last direction set with SetDirection
+func (r *RoboEyes) GetDirection() Direction {
+	return r.direction
 }
 
 // GetScreenConstraintX returns maximum X position for left eye
@@ -389,6 +662,7 @@ func (r *RoboEyes) SetAutoBlinkerWithInterval(active bool, interval, variation u
 }
 
 func (r *RoboEyes) SetAutoBlinker(active bool) {
+	r.interact()
 	r.autoblinker = active
 }
 
@@ -400,53 +674,79 @@ func (r *RoboEyes) SetIdleModeWithInterval(active bool, interval, variation uint
 }
 
 func (r *RoboEyes) SetIdleMode(active bool) {
+	r.interact()
 	r.idle = active
 }
 
 // SetCuriosity enables/disables curious gaze effect
 func (r *RoboEyes) SetCuriosity(active bool) {
+	r.interact()
 	r.curious = active
 }
 
 // SetCyclops enables/disables single eye mode
 func (r *RoboEyes) SetCyclops(active bool) {
+	r.interact()
 	r.cyclops = active
 }
 
 // SetHFlicker configures horizontal flicker effect
 func (r *RoboEyes) SetHFlicker(active bool, amplitude int16) {
+	r.interact()
+	r.setHFlicker(active, amplitude)
+}
+
+func (r *RoboEyes) setHFlicker(active bool, amplitude int16) {
 	r.hFlicker = active
 	r.hFlickerAmplitude = amplitude
 }
 
 // SetVFlicker configures vertical flicker effect
 func (r *RoboEyes) SetVFlicker(active bool, amplitude int16) {
+	r.interact()
+	r.setVFlicker(active, amplitude)
+}
+
+func (r *RoboEyes) setVFlicker(active bool, amplitude int16) {
 	r.vFlicker = active
 	r.vFlickerAmplitude = amplitude
 }
 
 // Close closes both eyes
 func (r *RoboEyes) Close() {
-	r.eyeLheightNext = 1
-	r.eyeRheightNext = 1
-	r.eyeL_open = false
-	r.eyeR_open = false
+	r.CloseEyes(true, true)
 }
 
 // Open opens both eyes
 func (r *RoboEyes) Open() {
-	r.eyeL_open = true
-	r.eyeR_open = true
+	r.OpenEyes(true, true)
 }
 
 // Blink performs a blink animation
 func (r *RoboEyes) Blink() {
-	r.Close()
-	r.Open()
+	r.BlinkEyes(true, true)
 }
 
 // CloseEyes closes specified eyes
 func (r *RoboEyes) CloseEyes(left, right bool) {
+	r.interact()
+	r.closeEyes(left, right)
+}
+
+// OpenEyes opens specified eyes
+func (r *RoboEyes) OpenEyes(left, right bool) {
+	r.interact()
+	r.openEyes(left, right)
+}
+
+// BlinkEyes blinks specified eyes
+func (r *RoboEyes) BlinkEyes(left, right bool) {
+	r.interact()
+	r.blink(left, right)
+}
+
+// closeEyes closes specified eyes, for animations
+func (r *RoboEyes) closeEyes(left, right bool) {
 	if left {
 		r.eyeLheightNext = 1
 		r.eyeL_open = false
@@ -457,8 +757,8 @@ func (r *RoboEyes) CloseEyes(left, right bool) {
 	}
 }
 
-// OpenEyes opens specified eyes
-func (r *RoboEyes) OpenEyes(left, right bool) {
+// openEyes opens specified eyes, for animations
+func (r *RoboEyes) openEyes(left, right bool) {
 	if left {
 		r.eyeL_open = true
 	}
@@ -467,58 +767,118 @@ func (r *RoboEyes) OpenEyes(left, right bool) {
 	}
 }
 
-// BlinkEyes blinks specified eyes
-func (r *RoboEyes) BlinkEyes(left, right bool) {
-	r.CloseEyes(left, right)
-	r.OpenEyes(left, right)
-}
-
 // AnimConfused triggers confused animation
 func (r *RoboEyes) AnimConfused() {
-	r.confused = true
+	r.interact()
+	r.schedule(animationCall{animation: AnimationConfused})
 }
 
 // AnimLaugh triggers laugh animation
 func (r *RoboEyes) AnimLaugh() {
-	r.laugh = true
+	r.interact()
+	r.schedule(animationCall{animation: AnimationLaugh})
 }
 
 // DrawEyes renders the eyes on the display
 func (r *RoboEyes) DrawEyes() {
+	if r.Render() {
+		// Update physical display, only the changed region when supported
+		r.display()
+	}
+}
+
+// Render draws the eyes into the device buffer without displaying them, so
+// that other content can be drawn before calling Display once per frame.
+// It returns false when nothing changed since the last frame, the buffer
+// then still holds the previous frame: call Invalidate after clearing it.
+func (r *RoboEyes) Render() bool {
+	frame, changed := r.advance()
+	if !changed {
+		return false
+	}
+
+	// Prepare display
+	r.clear()
+
+	r.drawFrame(frame)
+	return true
+}
+
+// advance moves geometry and animations to the current time and returns the
+// frame to draw, changed is false when it is the frame already drawn
+func (r *RoboEyes) advance() (frame frameState, changed bool) {
 	currentTime := r.millis()
 
+	// Smooth the gaze without the nod and shake of the last frame
+	r.removeSway()
+
 	// Calculate eye geometry with smoothing
 	r.calculateGeometry()
 
 	// Handle automatic animations
 	r.handleAnimations(currentTime)
 
-	// Prepare display
-	r.device.ClearBuffer()
+	// Move eyelids towards the current mood
+	r.updateEyelids()
+
+	// Fade eye color towards the current mood color
+	r.updateColors(currentTime)
+
+	// Skip the frame entirely when nothing moved since the last one,
+	// custom layers may change at any time
+	frame = r.frameState()
+	return frame, !r.frameValid || frame != r.lastFrame || len(r.layers) > 0
+}
+
+// drawFrame draws the eyes and custom layers into the cleared buffer
+func (r *RoboEyes) drawFrame(frame frameState) {
+	// Draw custom layers below the eyes
+	next := r.drawLayers(0, LayerEyes)
+
+	// Cut eyelids out of the eyes and the layers up to the eyelids
+	if r.lidMasks {
+		r.buildLidMask()
+		r.masking = true
+	}
 
 	// Draw eyes
 	r.drawEyeShapes()
+	next = r.drawLayers(next, LayerLids)
+	r.masking = false
+
+	// Draw eyelids based on mood, showing the background picture if any
+	if !r.lidMasks {
+		r.backdrop = r.background != nil
+		r.drawEyelids()
+		r.backdrop = false
+	}
+
+	// Draw Zzz above sleeping eyes
+	if frame.zzzStep > 0 {
+		r.drawZzz(frame.zzzStep)
+	}
 
-	// Draw eyelids based on mood
-	r.drawEyelids()
+	// Draw custom layers above the eyelids
+	r.drawLayers(next, math.MaxInt)
 
-	// Update physical display
-	r.device.Display()
+	r.commitFrame(frame)
+	r.emit(Event{Type: EventFrameRendered})
 }
 
 // calculateGeometry updates eye positions and sizes with smoothing
 func (r *RoboEyes) calculateGeometry() {
 	// Apply curious effect (enlarge outer eye)
 	if r.curious {
-		if r.eyeLxNext <= 10 {
-			r.eyeLheightOffset = 8
-		} else if r.eyeLxNext >= (r.GetScreenConstraintX()-10) && r.cyclops {
-			r.eyeLheightOffset = 8
+		edge, offset := r.Scaled(10), r.Scaled(8)
+		if r.eyeLxNext <= edge {
+			r.eyeLheightOffset = offset
+		} else if r.eyeLxNext >= (r.GetScreenConstraintX()-edge) && r.cyclops {
+			r.eyeLheightOffset = offset
 		} else {
 			r.eyeLheightOffset = 0
 		}
-		if r.eyeRxNext >= r.screenWidth-r.eyeRwidthCurrent-10 {
-			r.eyeRheightOffset = 8
+		if r.eyeRxNext >= r.screenWidth-r.eyeRwidthCurrent-edge {
+			r.eyeRheightOffset = offset
 		} else {
 			r.eyeRheightOffset = 0
 		}
@@ -551,6 +911,7 @@ func (r *RoboEyes) calculateGeometry() {
 
 	// Space between eyes smoothing
 	r.spaceBetweenCurrent = (r.spaceBetweenCurrent + r.spaceBetweenNext) / 2
+	r.keepDualSpace()
 
 	// Position smoothing
 	r.eyeLx = (r.eyeLx + r.eyeLxNext) / 2
@@ -567,45 +928,68 @@ func (r *RoboEyes) calculateGeometry() {
 
 // handleAnimations processes automatic and triggered animations
 func (r *RoboEyes) handleAnimations(currentTime uint32) {
-	// Automatic blinking
-	if r.autoblinker && currentTime >= r.blinktimer {
-		r.Blink()
-		r.blinktimer = currentTime + r.blinkInterval + uint32(rand.Intn(int(r.blinkIntervalVariation)))
+	// Fall asleep after the inactivity timeout, wake up when asked to
+	r.animateSleep(currentTime)
+
+	// Startle reaction
+	r.animateStartle(currentTime)
+
+	// Start the animations waiting for a free channel
+	r.runQueues()
+
+	// Report blinks whose eyes opened again
+	r.trackBlink()
+
+	// Automatic blinking, waiting for triggered animations
+	if r.autoblinker && !r.animating() && r.sleep == sleepAwake && currentTime >= r.blinktimer {
+		r.blink(true, true)
+		r.blinktimer = currentTime + r.blinkInterval + randomVariation(r.blinkIntervalVariation)
 	}
 
 	// Laugh animation (vertical shaking)
 	if r.laugh {
 		if r.laughToggle {
-			r.SetVFlicker(true, 5)
+			r.setVFlicker(true, r.Scaled(5))
 			r.laughAnimationTimer = currentTime
 			r.laughToggle = false
 		} else if currentTime >= r.laughAnimationTimer+r.laughAnimationDuration {
-			r.SetVFlicker(false, 0)
+			r.setVFlicker(false, 0)
 			r.laughToggle = true
 			r.laugh = false
+			r.animationDone(AnimationLaugh, false)
 		}
 	}
 
 	// Confused animation (horizontal shaking)
 	if r.confused {
 		if r.confusedToggle {
-			r.SetHFlicker(true, 20)
+			r.setHFlicker(true, r.Scaled(20))
 			r.confusedAnimationTimer = currentTime
 			r.confusedToggle = false
 		} else if currentTime >= r.confusedAnimationTimer+r.confusedAnimationDuration {
-			r.SetHFlicker(false, 0)
+			r.setHFlicker(false, 0)
 			r.confusedToggle = true
 			r.confused = false
+			r.animationDone(AnimationConfused, false)
 		}
 	}
 
 	// Idle mode (random eye movements)
-	if r.idle && currentTime >= r.idleAnimationTimer {
-		r.eyeLxNext = int16(rand.Intn(int(r.GetScreenConstraintX())))
-		r.eyeLyNext = int16(rand.Intn(int(r.GetScreenConstraintY())))
-		r.idleAnimationTimer = currentTime + r.idleInterval + uint32(rand.Intn(int(r.idleIntervalVariation)))
+	if r.idle && !r.channels[ChannelGaze].busy && !r.startle && r.sleep == sleepAwake && currentTime >= r.idleAnimationTimer {
+		r.eyeLxNext = randomPosition(r.GetScreenConstraintX())
+		r.eyeLyNext = randomPosition(r.GetScreenConstraintY())
+		r.idleAnimationTimer = currentTime + r.idleInterval + randomVariation(r.idleIntervalVariation)
+		r.gazeTargetChanged()
 	}
 
+	// Wink, squint and eye roll animations
+	r.animateWink(currentTime)
+	r.animateSquint(currentTime)
+	r.animateEyeRoll(currentTime)
+
+	// Nod and shake animations
+	r.applySway(currentTime)
+
 	// Apply horizontal flicker
 	if r.hFlicker {
 		if r.hFlickerAlternate {
@@ -638,26 +1022,65 @@ func (r *RoboEyes) handleAnimations(currentTime uint32) {
 	}
 }
 
+// randomPosition returns a random coordinate in [0, limit), or 0 when the
+// eyes do not fit on the screen
+func randomPosition(limit int16) int16 {
+	if limit <= 0 {
+		return 0
+	}
+	return int16(rand.Intn(int(limit)))
+}
+
+// randomVariation returns a random delay in [0, variation), or 0 when variation is 0
+func randomVariation(variation uint32) uint32 {
+	if variation == 0 {
+		return 0
+	}
+	// Int63n as int is 32 bits wide on microcontrollers
+	return uint32(rand.Int63n(int64(variation)))
+}
+
 // drawEyeShapes renders the main eye shapes
 func (r *RoboEyes) drawEyeShapes() {
 	// Convert border radius to int16 for drawing
 	borderL := int16(r.eyeLborderRadiusCurrent)
 	borderR := int16(r.eyeRborderRadiusCurrent)
 
-	// Draw left eye
-	r.fillRoundRect(
+	fill := r.fillRoundRect
+	if r.antialias {
+		fill = r.fillRoundRectAA
+	}
+
+	// Prepare the shaders of both eyes
+	r.shaders[0].prepare(
+		r.fillStyles[0],
 		r.eyeLx, r.eyeLy,
 		r.eyeLwidthCurrent, r.eyeLheightCurrent,
-		borderL, r.eyesColor,
+		borderL, r.eyesColor, r.bgColor,
+	)
+	r.shaders[1].prepare(
+		r.fillStyles[1],
+		r.eyeRx, r.eyeRy,
+		r.eyeRwidthCurrent, r.eyeRheightCurrent,
+		borderR, r.eyesColor, r.bgColor,
 	)
 
-	// Draw right eye unless in cyclops mode
-	if !r.cyclops {
-		r.fillRoundRect(
-			r.eyeRx, r.eyeRy,
-			r.eyeRwidthCurrent, r.eyeRheightCurrent,
-			borderR, r.eyesColor,
-		)
+	// Draw halos first so they never cover the other eye
+	eyes := r.visibleShaders()
+	for i := range eyes {
+		if eyes[i].style.Halo > 0 {
+			r.drawHalo(&eyes[i])
+		}
+	}
+
+	// Draw eyes, styled eyes are shaded pixel by pixel by the rasterizers
+	for i := range eyes {
+		s := &eyes[i]
+		if !s.plain() {
+			r.shading = s
+		}
+		fill(s.x, s.y, s.width, s.height, s.radius, r.eyesColor)
+		r.shading = nil
 	}
 }
 
@@ -704,6 +1127,10 @@ func (r *RoboEyes) fillCircle(x0, y0, radius int16, corners int, c color.RGBA) {
 }
 
 func (r *RoboEyes) fillRoundRect(x, y, width, height, radius int16, c color.RGBA) {
+	if r.recording {
+		r.mask.addRoundRect(x, y, width, height, radius)
+		return
+	}
 	if height <= 2 || width <= 2 {
 		r.fillRect(x, y, width, height, c)
 		return
@@ -745,29 +1172,113 @@ func (r *RoboEyes) drawFastHLine(x, y, length int16, c color.RGBA) {
 		return
 	}
 
-	// Fallback to manual pixel drawing
-	end := x + length
-	if end > r.screenWidth {
-		end = r.screenWidth
-	}
-	for ; x < end; x++ {
-		r.device.SetPixel(x, y, c)
-	}
+	r.fillSpan(x, x+length-1, y, c)
 }
 
 // fillRect fills a rectangle with color
 func (r *RoboEyes) fillRect(x, y, width, height int16, c color.RGBA) {
-	for i := x; i < x+width; i++ {
-		for j := y; j < y+height; j++ {
-			if i >= 0 && i < r.screenWidth && j >= 0 && j < r.screenHeight {
-				r.device.SetPixel(i, j, c)
-			}
+	// Clip rectangle to screen bounds
+	if x < 0 {
+		width += x
+		x = 0
+	}
+	if y < 0 {
+		height += y
+		y = 0
+	}
+	if x+width > r.screenWidth {
+		width = r.screenWidth - x
+	}
+	if y+height > r.screenHeight {
+		height = r.screenHeight - y
+	}
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	// Use the device rectangle fill when available, one span per row otherwise
+	if r.rectFill != nil && r.shading == nil && !r.backdrop && !r.masking && (!r.dithering() || solidColor(c)) {
+		r.deviceRect(x, y, width, height, c)
+		return
+	}
+	for j := y; j < y+height; j++ {
+		r.fillSpan(x, x+width-1, j, c)
+	}
+}
+
+// setPixel sets a single pixel, clipped to the screen
+func (r *RoboEyes) setPixel(x, y int16, c color.RGBA) {
+	if x < 0 || x >= r.screenWidth || y < 0 || y >= r.screenHeight {
+		return
+	}
+	if r.masking && r.mask.covers(x, y) {
+		return
+	}
+	if r.dithering() {
+		c = r.ditherColor(x, y, c)
+	}
+	r.devicePixel(x, y, c)
+}
+
+// fillSpan fills pixels x0 to x1 (inclusive) of row y, clipped to the screen.
+// It is the common path of every rasterizer and picks the fastest primitive
+// offered by the device, falling back to SetPixel.
+func (r *RoboEyes) fillSpan(x0, x1, y int16, c color.RGBA) {
+	if y < 0 || y >= r.screenHeight {
+		return
+	}
+	if x0 < 0 {
+		x0 = 0
+	}
+	if x1 >= r.screenWidth {
+		x1 = r.screenWidth - 1
+	}
+	if x0 > x1 {
+		return
+	}
+
+	if r.masking {
+		r.maskSpan(x0, x1, y, c)
+		return
+	}
+	r.drawSpan(x0, x1, y, c)
+}
+
+// drawSpan fills pixels x0 to x1 of row y, which must be on screen, with the
+// active shader, the background picture or with c
+func (r *RoboEyes) drawSpan(x0, x1, y int16, c color.RGBA) {
+	if r.shading != nil {
+		r.shadeSpan(x0, x1, y)
+		return
+	}
+	if r.backdrop {
+		r.backgroundSpan(x0, x1, y)
+		return
+	}
+	r.paintSpan(x0, x1, y, c)
+}
+
+// paintSpan fills pixels x0 to x1 of row y, which must be on screen
+func (r *RoboEyes) paintSpan(x0, x1, y int16, c color.RGBA) {
+	switch {
+	case r.dithering() && !solidColor(c):
+		// Intermediate intensity, each pixel gets its own pattern value
+		for x := x0; x <= x1; x++ {
+			r.devicePixel(x, y, r.ditherColor(x, y, c))
+		}
+	case r.hLine != nil && !r.transposed():
+		r.deviceHLine(x0, x1, y, c)
+	case r.rectFill != nil:
+		r.deviceRect(x0, y, x1-x0+1, 1, c)
+	default:
+		for x := x0; x <= x1; x++ {
+			r.devicePixel(x, y, c)
 		}
 	}
 }
 
-// drawEyelids renders animated eyelids based on current emotional state
-func (r *RoboEyes) drawEyelids() {
+// updateEyelids moves eyelids towards the positions of the current mood
+func (r *RoboEyes) updateEyelids() {
 	// 1. Determine target eyelid positions based on active emotions
 	// Reset all states first to ensure clean transitions
 	r.eyelidsTiredHeightNext = 0
@@ -775,7 +1286,7 @@ func (r *RoboEyes) drawEyelids() {
 	r.eyelidsHappyBottomOffsetNext = 0
 
 	// Set next positions based on active emotions (with priority handling)
-	if r.tired {
+	if r.tired || r.drowsy {
 		r.eyelidsTiredHeightNext = r.eyeLheightCurrent / 2
 	} else if r.angry {
 		r.eyelidsAngryHeightNext = r.eyeLheightCurrent / 2
@@ -789,12 +1300,15 @@ func (r *RoboEyes) drawEyelids() {
 	r.eyelidsTiredHeight = (r.eyelidsTiredHeight + r.eyelidsTiredHeightNext) / 2
 	r.eyelidsAngryHeight = (r.eyelidsAngryHeight + r.eyelidsAngryHeightNext) / 2
 	r.eyelidsHappyBottomOffset = (r.eyelidsHappyBottomOffset + r.eyelidsHappyBottomOffsetNext) / 2
+}
 
+// drawEyelids renders animated eyelids based on current emotional state
+func (r *RoboEyes) drawEyelids() {
 	// Precompute common Y positions for efficiency
 	eyeTopY := r.eyeLy - 1 // Top edge of eyes
 	eyeBottomY := r.eyeLy + r.eyeLheightCurrent
 
-	// 3. Render tired eyelids - droopy triangles from top corners
+	// 1. Render tired eyelids - droopy triangles from top corners
 	if r.eyelidsTiredHeight > 0 {
 		r.drawEyelidTriangles(
 			r.eyeLx, eyeTopY, r.eyeLwidthCurrent,
@@ -805,7 +1319,7 @@ func (r *RoboEyes) drawEyelids() {
 		)
 	}
 
-	// 4. Render angry eyelids - inward slanting triangles
+	// 2. Render angry eyelids - inward slanting triangles
 	if r.eyelidsAngryHeight > 0 {
 		r.drawEyelidTriangles(
 			r.eyeLx, eyeTopY, r.eyeLwidthCurrent,
@@ -816,7 +1330,7 @@ func (r *RoboEyes) drawEyelids() {
 		)
 	}
 
-	// 5. Render happy eyelids - bottom curved covers
+	// 3. Render happy eyelids - bottom curved covers
 	if r.eyelidsHappyBottomOffset > 0 {
 		// Left eye bottom cover
 		r.fillRoundRect(
@@ -933,19 +1447,21 @@ func (r *RoboEyes) drawEyelidTriangles(
 
 // fillTriangle fills a triangle with the specified color using scanline rasterization
 func (r *RoboEyes) fillTriangle(x0, y0, x1, y1, x2, y2 int16, c color.RGBA) {
-	// Sort vertices by ascending y-coordinate (y0 <= y1 <= y2)
-	if y0 > y1 {
-		x0, x1 = x1, x0
-		y0, y1 = y1, y0
-	}
-	if y1 > y2 {
-		x1, x2 = x2, x1
-		y1, y2 = y2, y1
+	if r.recording {
+		r.mask.addTriangle(x0, y0, x1, y1, x2, y2)
+		return
 	}
-	if y0 > y1 {
-		x0, x1 = x1, x0
-		y0, y1 = y1, y0
+	if r.antialias {
+		r.fillTriangleAA(x0, y0, x1, y1, x2, y2, c)
+		return
 	}
+	r.fillTriangleAliased(x0, y0, x1, y1, x2, y2, c)
+}
+
+// fillTriangleAliased fills a triangle without anti-aliasing
+func (r *RoboEyes) fillTriangleAliased(x0, y0, x1, y1, x2, y2 int16, c color.RGBA) {
+	// Sort vertices by ascending y-coordinate (y0 <= y1 <= y2)
+	x0, y0, x1, y1, x2, y2 = sortTriangle(x0, y0, x1, y1, x2, y2)
 
 	totalHeight := y2 - y0
 	if totalHeight == 0 {
@@ -955,67 +1471,174 @@ func (r *RoboEyes) fillTriangle(x0, y0, x1, y1, x2, y2 int16, c color.RGBA) {
 	topHeight := y1 - y0    // Height of top segment
 	bottomHeight := y2 - y1 // Height of bottom segment
 
-	// Precompute inverse heights once (avoid division in loops)
-	invTotalHeight := 1.0 / float32(totalHeight)
-	var invTopHeight, invBottomHeight float32
+	// Long edge (x0 to x2) is walked across both segments
+	long := newEdgeWalker(x0, x2-x0, totalHeight)
 
 	// Render top segment (from y0 to y1-1)
 	if topHeight > 0 {
-		invTopHeight = 1.0 / float32(topHeight)
+		top := newEdgeWalker(x0, x1-x0, topHeight) // Top edge (x0 to x1)
 		for y := y0; y < y1; y++ {
-			// Calculate x-coordinates on left and right edges
-			t := float32(y-y0) * invTotalHeight
-			ax := x0 + int16(float32(x2-x0)*t) // Long edge (x0 to x2)
-
-			t = float32(y-y0) * invTopHeight
-			bx := x0 + int16(float32(x1-x0)*t) // Top edge (x0 to x1)
-
-			// Draw horizontal scanline between ax and bx
-			r.drawHorizontalLine(ax, bx, y, c)
+			// Draw horizontal scanline between both edges
+			r.drawHorizontalLine(long.pixel(), top.pixel(), y, c)
+			long.next()
+			top.next()
 		}
 	}
 
 	// Render bottom segment (from y1 to y2)
 	if bottomHeight > 0 {
-		invBottomHeight = 1.0 / float32(bottomHeight)
+		bottom := newEdgeWalker(x1, x2-x1, bottomHeight) // Bottom edge (x1 to x2)
 		for y := y1; y <= y2; y++ {
-			// Calculate x-coordinates on left and right edges
-			t := float32(y-y0) * invTotalHeight
-			ax := x0 + int16(float32(x2-x0)*t) // Long edge (x0 to x2)
-
-			t = float32(y-y1) * invBottomHeight
-			bx := x1 + int16(float32(x2-x1)*t) // Bottom edge (x1 to x2)
-
-			// Draw horizontal scanline between ax and bx
-			r.drawHorizontalLine(ax, bx, y, c)
+			// Draw horizontal scanline between both edges
+			r.drawHorizontalLine(long.pixel(), bottom.pixel(), y, c)
+			long.next()
+			bottom.next()
 		}
 	}
 	// Handle flat-bottom triangles (topHeight=0) where only bottom part renders
 }
 
-// drawHorizontalLine draws a clipped horizontal line efficiently
-// Arguments are assumed to have: y within [0, screenHeight-1]
-func (r *RoboEyes) drawHorizontalLine(xA, xB, y int16, c color.RGBA) {
-	// Ensure we draw from left to right
-	if xA > xB {
-		xA, xB = xB, xA
+// sortTriangle orders triangle vertices by ascending y-coordinate
+func sortTriangle(x0, y0, x1, y1, x2, y2 int16) (int16, int16, int16, int16, int16, int16) {
+	if y0 > y1 {
+		x0, x1 = x1, x0
+		y0, y1 = y1, y0
+	}
+	if y1 > y2 {
+		x1, x2 = x2, x1
+		y1, y2 = y2, y1
 	}
+	if y0 > y1 {
+		x0, x1 = x1, x0
+		y0, y1 = y1, y0
+	}
+	return x0, y0, x1, y1, x2, y2
+}
 
-	// Early exit if scanline is completely off-screen vertically
-	if y < 0 || y >= r.screenHeight {
-		return
+// edgeWalker steps along a triangle edge one scanline at a time using only
+// integer additions, which keeps fillTriangle fast on FPU-less MCUs.
+// After k steps x equals x0 + dx*k/dy truncated towards zero.
+type edgeWalker struct {
+	x    int16 // Current x coordinate
+	step int16 // Whole pixels added per scanline
+	sign int16 // Direction of the carry, -1 or 1
+	rem  int16 // Fractional part of dx/dy, as a numerator over dy
+	dy   int16 // Edge height
+	err  int16 // Accumulated fraction, always below dy
+
+	x0, dx, k int16  // Edge start, width and scanlines walked, for pixel
+	wide      bool   // Edge too large for float32 rounding to only matter on whole pixels
+	recip     uint64 // 1/dy rounded to float32, as recip*2^recipExp
+	recipExp  int
+}
+
+// newEdgeWalker prepares the walk of an edge starting at x0 that moves dx
+// pixels over dy scanlines, dy must be positive
+func newEdgeWalker(x0, dx, dy int16) edgeWalker {
+	e := edgeWalker{x: x0, sign: 1, dy: dy, x0: x0, dx: dx}
+	e.wide = !edgeExact(dx, dy)
+	e.recip, e.recipExp = edgeReciprocal(dy)
+	if dx < 0 {
+		e.sign, dx = -1, -dx
+	}
+	e.step, e.rem = e.sign*(dx/dy), dx%dy
+	return e
+}
+
+// next advances the edge to the following scanline
+func (e *edgeWalker) next() {
+	e.k++
+	e.x += e.step
+	e.err += e.rem
+	if e.err >= e.dy {
+		e.err -= e.dy
+		e.x += e.sign
+	}
+}
+
+// pixel returns the last pixel of the edge on the current scanline, which
+// is x unless a slanted edge crosses the scanline on a pixel boundary
+func (e *edgeWalker) pixel() int16 {
+	if e.k > 0 && e.dx != 0 && (e.err == 0 || e.wide) {
+		return e.x0 + edgeProduct(e.dx, e.k, e.recip, e.recipExp)
 	}
+	return e.x
+}
 
-	// Clip x-coordinates to screen bounds
-	if xA < 0 {
-		xA = 0
+// edgeOffset returns how far an edge moving dx pixels over dy scanlines is
+// from its start after k scanlines, truncated towards zero exactly like the
+// float32 rasterizer fillTriangle used to be: dx*(k*(1/dy)) rounded to
+// float32 after each operation. Rounding only moves the result to another
+// pixel when dx*k/dy is whole, or for edges too large for edgeExact, which
+// are the only cases where the float32 product is emulated.
+func edgeOffset(dx, k, dy int16) int16 {
+	n := int32(dx) * int32(k)
+	if n%int32(dy) != 0 && edgeExact(dx, dy) {
+		return int16(n / int32(dy))
 	}
-	if xB >= r.screenWidth {
-		xB = r.screenWidth - 1
+	recip, exp := edgeReciprocal(dy)
+	return edgeProduct(dx, k, recip, exp)
+}
+
+// edgeReciprocal returns 1/dy rounded to a float32, as m*2^exp
+func edgeReciprocal(dy int16) (m uint64, exp int) {
+	return roundFloat32(1<<40/uint64(dy), -40, 1<<40%uint64(dy) != 0)
+}
+
+// edgeProduct returns dx*(k*recip) truncated towards zero, rounded to
+// float32 after each operation, recip being 1/dy from edgeReciprocal
+func edgeProduct(dx, k int16, recip uint64, exp int) int16 {
+	width := uint64(dx)
+	if dx < 0 {
+		width = uint64(-int32(dx))
 	}
+	m, exp := roundFloat32(recip*uint64(k), exp, false)
+	m, exp = roundFloat32(m*width, exp, false)
+	if exp < 0 {
+		m >>= -exp
+	} else {
+		m <<= exp
+	}
+	if dx < 0 {
+		return -int16(m)
+	}
+	return int16(m)
+}
 
-	// Draw visible portion of the scanline
-	for x := xA; x <= xB; x++ {
-		r.device.SetPixel(x, y, c)
+// edgeExact reports whether float32 rounding is too small to cross a pixel
+// boundary on an edge unless dx*k/dy is whole: the rounding error stays
+// below |dx|*3/2^24 pixels while other crossings are at least 1/dy away
+func edgeExact(dx, dy int16) bool {
+	width := int32(dx)
+	if width < 0 {
+		width = -width
+	}
+	return width*int32(dy) < 1<<22
+}
+
+// roundFloat32 rounds m*2^exp to the 24 significant bits of a float32,
+// to nearest with ties to even. sticky tells that m was already truncated
+// from a slightly larger value.
+func roundFloat32(m uint64, exp int, sticky bool) (uint64, int) {
+	shift := bits.Len64(m) - 24
+	if shift <= 0 {
+		return m, exp
 	}
+	rest, half := m&(1<<shift-1), uint64(1)<<(shift-1)
+	m >>= shift
+	if rest > half || rest == half && (sticky || m&1 == 1) {
+		m++
+	}
+	return m, exp + shift
+}
+
+// drawHorizontalLine draws a clipped horizontal line efficiently
+func (r *RoboEyes) drawHorizontalLine(xA, xB, y int16, c color.RGBA) {
+	// Ensure we draw from left to right
+	if xA > xB {
+		xA, xB = xB, xA
+	}
+
+	// Draw visible portion of the scanline
+	r.fillSpan(xA, xB, y, c)
 }
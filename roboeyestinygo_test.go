@@ -0,0 +1,171 @@
+package roboeyestinygo
+
+import (
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+// fillTriangleFloat is the float32 scanline rasterizer fillTriangle replaced
+func (r *RoboEyes) fillTriangleFloat(x0, y0, x1, y1, x2, y2 int16, c color.RGBA) {
+	x0, y0, x1, y1, x2, y2 = sortTriangle(x0, y0, x1, y1, x2, y2)
+	totalHeight := y2 - y0
+	if totalHeight == 0 {
+		return
+	}
+	topHeight := y1 - y0
+	bottomHeight := y2 - y1
+	invTotalHeight := 1.0 / float32(totalHeight)
+	if topHeight > 0 {
+		invTopHeight := 1.0 / float32(topHeight)
+		for y := y0; y < y1; y++ {
+			t := float32(y-y0) * invTotalHeight
+			ax := x0 + int16(float32(x2-x0)*t)
+			t = float32(y-y0) * invTopHeight
+			bx := x0 + int16(float32(x1-x0)*t)
+			r.drawHorizontalLine(ax, bx, y, c)
+		}
+	}
+	if bottomHeight > 0 {
+		invBottomHeight := 1.0 / float32(bottomHeight)
+		for y := y1; y <= y2; y++ {
+			t := float32(y-y0) * invTotalHeight
+			ax := x0 + int16(float32(x2-x0)*t)
+			t = float32(y-y1) * invBottomHeight
+			bx := x1 + int16(float32(x2-x1)*t)
+			r.drawHorizontalLine(ax, bx, y, c)
+		}
+	}
+}
+
+// triangleDiffers reports whether both rasterizers draw different spans
+func triangleDiffers(r *RoboEyes, d *spanDevice, v [6]int16) bool {
+	d.spans = d.spans[:0]
+	r.fillTriangleFloat(v[0], v[1], v[2], v[3], v[4], v[5], white)
+	want := len(d.spans)
+	r.fillTriangleAliased(v[0], v[1], v[2], v[3], v[4], v[5], white)
+	if len(d.spans) != 2*want {
+		return true
+	}
+	for i := 0; i < want; i++ {
+		if d.spans[i] != d.spans[want+i] {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFillTriangleMatchesFloat32(t *testing.T) {
+	d := &spanDevice{testDevice: *newTestDevice(240, 240)}
+	r := newTestEyes(d, 50)
+
+	// Eyelid shapes: right triangles and halves of the eye width
+	for w := int16(1); w <= 200; w++ {
+		for h := int16(1); h <= 120; h++ {
+			x, y := int16(10), int16(5)
+			for _, v := range [...][6]int16{
+				{x, y, x + w, y, x, y + h},
+				{x, y, x + w, y, x + w, y + h},
+				{x, y, x + w/2, y, x + w/2, y + h},
+				{x + w/2, y, x + w, y, x + w/2, y + h},
+			} {
+				if triangleDiffers(r, d, v) {
+					t.Fatalf("eyelid triangle %v differs", v)
+				}
+			}
+		}
+	}
+
+	// Random triangles around the screen, then large ones mostly off screen
+	rnd := rand.New(rand.NewSource(1))
+	for _, c := range [...]struct{ span, n int }{{260, 20000}, {8000, 2000}} {
+		for i := 0; i < c.n; i++ {
+			var v [6]int16
+			for k := range v {
+				v[k] = int16(rnd.Intn(c.span) - c.span/2 + 120)
+			}
+			if triangleDiffers(r, d, v) {
+				t.Fatalf("triangle %v differs", v)
+			}
+		}
+	}
+}
+
+func TestEdgeOffsetMatchesFloat32(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	for i := 0; i < 200000; i++ {
+		dy := int16(rnd.Intn(4000) + 1)
+		dx := int16(rnd.Intn(8001) - 4000)
+		k := int16(rnd.Intn(int(dy) + 1))
+		want := int16(float32(dx) * (float32(k) * (1.0 / float32(dy))))
+		if got := edgeOffset(dx, k, dy); got != want {
+			t.Fatalf("edgeOffset(%d, %d, %d) = %d, want %d", dx, k, dy, got, want)
+		}
+	}
+}
+
+type nullDevice struct{}
+
+func (nullDevice) ClearBuffer()                      {}
+func (nullDevice) Display() error                    { return nil }
+func (nullDevice) SetPixel(x, y int16, c color.RGBA) {}
+func (nullDevice) Size() (int16, int16)              { return 240, 240 }
+
+func BenchmarkTriFloat(b *testing.B) {
+	r := newTestEyes(nullDevice{}, 50)
+	for i := 0; i < b.N; i++ {
+		r.fillTriangleFloat(10, 10, 120, 10, 10, 100, white)
+	}
+}
+
+func BenchmarkTriFixed(b *testing.B) {
+	r := newTestEyes(nullDevice{}, 50)
+	for i := 0; i < b.N; i++ {
+		r.fillTriangleAliased(10, 10, 120, 10, 10, 100, white)
+	}
+}
+
+// nullSpanDevice takes whole spans, so benchmarks through it measure the
+// rasterizers rather than SetPixel calls
+type nullSpanDevice struct{ nullDevice }
+
+func (nullSpanDevice) DrawFastHLine(x0, x1, y int16, c color.RGBA) {}
+
+func BenchmarkTriFloatSpans(b *testing.B) {
+	r := newTestEyes(nullSpanDevice{}, 50)
+	for i := 0; i < b.N; i++ {
+		r.fillTriangleFloat(10, 10, 120, 10, 10, 100, white)
+	}
+}
+
+func BenchmarkTriFixedSpans(b *testing.B) {
+	r := newTestEyes(nullSpanDevice{}, 50)
+	for i := 0; i < b.N; i++ {
+		r.fillTriangleAliased(10, 10, 120, 10, 10, 100, white)
+	}
+}
+
+func TestFastPathsDrawSamePixels(t *testing.T) {
+	plain, fast := newTestDevice(128, 64), newFastDevice(128, 64)
+	a, b := newTestEyes(plain, 50), newTestEyes(fast, 50)
+	moves := []func(r *RoboEyes){
+		func(r *RoboEyes) { r.Open() },
+		func(r *RoboEyes) { r.SetMood(MoodHappy); r.SetDirection(DirNW) },
+		func(r *RoboEyes) { r.SetMood(MoodAngry); r.SetHFlicker(true, 6) },
+		func(r *RoboEyes) { r.SetMood(MoodTired); r.SetCyclops(true); r.SetDirection(DirSE) },
+	}
+	for i, move := range moves {
+		move(a)
+		move(b)
+		for k := 0; k < 10; k++ {
+			step(a, 1)
+			step(b, 1)
+			if n := diffPixels(plain, &fast.testDevice); n != 0 {
+				t.Fatalf("move %d frame %d: %d pixels differ", i, k, n)
+			}
+		}
+	}
+	if fast.rects == 0 || fast.lines == 0 {
+		t.Fatalf("%d rectangles and %d spans", fast.rects, fast.lines)
+	}
+}
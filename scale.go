@@ -0,0 +1,65 @@
+package roboeyestinygo
+
+// Screen the default geometry was designed for
+const (
+	referenceWidth  = 128
+	referenceHeight = 64
+)
+
+// Fixed point unit of the layout scale, scale 256 draws the reference design
+const scaleUnit = 256
+
+// SetAutoScale enables/disables scaling of the default geometry to the
+// screen. When enabled, eye sizes, border radius, spacing, flicker
+// amplitudes and the curiosity and animation offsets are those of the
+// 128x64 design scaled uniformly to fit the screen, so expressions look the
+// same on a 240x240 or 320x170 panel as on the OLED they were tuned on.
+// Enabling or disabling it resets the eye geometry to the defaults, setters
+// called afterwards still take pixels, use Scaled to convert.
+func (r *RoboEyes) SetAutoScale(active bool) {
+	r.autoScale = active
+	r.applyScale()
+}
+
+// Scaled converts a length of the 128x64 reference design to pixels on the
+// current screen, it returns v unchanged when auto-scale is disabled
+func (r *RoboEyes) Scaled(v int16) int16 {
+	return int16((int32(v)*r.scale + scaleUnit/2) / scaleUnit)
+}
+
+// scaledRadius converts a border radius of the reference design to pixels
+func (r *RoboEyes) scaledRadius(v byte) byte {
+	return byte(min(r.Scaled(int16(v)), 255))
+}
+
+// applyScale computes the layout scale of the screen and resets the eye
+// geometry to the scaled defaults
+func (r *RoboEyes) applyScale() {
+	r.scale = scaleUnit
+	if r.autoScale {
+		// Uniform scale so the eyes keep their proportions and fit both ways
+		r.scale = int32(r.screenWidth) * scaleUnit / referenceWidth
+		if s := int32(r.screenHeight) * scaleUnit / referenceHeight; s < r.scale {
+			r.scale = s
+		}
+		r.scale = max(r.scale, 1)
+	}
+
+	size := r.Scaled(36)
+	r.setSize(size, size)
+	r.setHeight(size, size)
+	radius := r.scaledRadius(8)
+	r.setBorderRadius(radius, radius)
+	r.setSpaceBetween(r.Scaled(10))
+	r.hFlickerAmplitude = r.Scaled(2)
+	r.vFlickerAmplitude = r.Scaled(10)
+
+	// Widths take effect at once, positions are computed from them
+	r.eyeLwidthCurrent = size
+	r.eyeRwidthCurrent = size
+	r.spaceBetweenCurrent = r.spaceBetweenDefault
+	r.keepDualSpace()
+
+	// Move the eyes to their position with the new geometry
+	r.setDirection(r.direction)
+}
@@ -0,0 +1,61 @@
+package roboeyestinygo
+
+import "testing"
+
+func TestAutoScale(t *testing.T) {
+	for _, tc := range []struct {
+		width, height int16
+		scale         int32
+	}{
+		{128, 64, 256}, {240, 240, 480}, {320, 170, 640}, {64, 128, 128},
+	} {
+		r := newTestEyes(newTestDevice(tc.width, tc.height), 50)
+		r.SetAutoScale(true)
+		if r.scale != tc.scale {
+			t.Errorf("%dx%d: scale %d, want %d", tc.width, tc.height, r.scale, tc.scale)
+		}
+		if r.eyeLwidthDefault != r.Scaled(36) || r.eyeLheightDefault != r.Scaled(36) ||
+			r.spaceBetweenDefault != r.Scaled(10) || r.eyeLborderRadiusDefault != r.scaledRadius(8) {
+			t.Errorf("%dx%d: eye %dx%d radius %d, space %d", tc.width, tc.height,
+				r.eyeLwidthDefault, r.eyeLheightDefault, r.eyeLborderRadiusDefault, r.spaceBetweenDefault)
+		}
+		// The eyes stay on the screen in every direction
+		r.Open()
+		for dir := DirCenter; dir <= DirNW; dir++ {
+			r.SetDirection(dir)
+			step(r, 20)
+			x, y, w, h := r.LeftEye()
+			rx, _, rw, _ := r.RightEye()
+			if x < 0 || y < 0 || y+h > tc.height || rx+rw > tc.width || w == 0 {
+				t.Errorf("%dx%d %d: eyes at %d,%d %dx%d and %d", tc.width, tc.height, dir, x, y, w, h, rx)
+			}
+		}
+	}
+}
+
+func TestScaledOff(t *testing.T) {
+	r := newTestEyes(newTestDevice(240, 240), 50)
+	for _, v := range []int16{0, 1, 10, 36} {
+		if got := r.Scaled(v); got != v {
+			t.Errorf("Scaled(%d) = %d without auto-scale", v, got)
+		}
+	}
+	r.SetAutoScale(true)
+	r.SetAutoScale(false)
+	if _, _, w, _ := r.LeftEye(); w != 36 {
+		t.Errorf("eye width %d after disabling auto-scale", w)
+	}
+}
+
+func TestAutoScaleFollowsRotation(t *testing.T) {
+	r := newTestEyes(newTestDevice(64, 128), 50)
+	r.SetAutoScale(true)
+	r.SetRotation(Rotate90)
+	if r.scale != scaleUnit {
+		t.Fatalf("rotated scale %d", r.scale)
+	}
+	r.SetRotation(Rotate0)
+	if r.scale != scaleUnit/2 {
+		t.Fatalf("scale %d back in portrait", r.scale)
+	}
+}
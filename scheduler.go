@@ -0,0 +1,226 @@
+package roboeyestinygo
+
+// Channel is a part of the eyes triggered animations compete for. Only one
+// animation plays on a channel at a time, animations on different channels
+// play together.
+type Channel byte
+
+const (
+	ChannelGaze  Channel = iota // Gaze target: eye roll
+	ChannelLids                 // Eye heights: wink, squint
+	ChannelShake                // Eye pair motion: laugh, confused, nod, shake
+	channelCount
+)
+
+// Policy selects what happens to an animation triggered while another one
+// plays on its channel with the same or a higher priority. Animations with a
+// higher priority than the playing one always interrupt it.
+type Policy byte
+
+const (
+	PolicyEnqueue Policy = iota // Play it after the animations already waiting, higher priorities first
+	PolicyReplace               // Interrupt the playing animation
+	PolicyIgnore                // Drop it
+)
+
+// Animations waiting per channel, more are dropped
+const maxQueuedAnimations = 4
+
+// Priority of triggered animations until changed with SetAnimationPriority
+const defaultAnimationPriority = 128
+
+// animationCall is a triggered animation with its parameters
+type animationCall struct {
+	animation Animation
+	priority  uint8
+	left      bool   // Wink
+	duration  uint32 // Wink, squint and eye roll
+	count     uint16 // Nod and shake
+	amplitude int16
+	period    uint32
+}
+
+// animationChannel holds the animation playing on a channel and those
+// waiting for it, sorted by decreasing priority
+type animationChannel struct {
+	playing animationCall
+	busy    bool
+	policy  Policy
+	queue   [maxQueuedAnimations]animationCall
+	queued  int
+}
+
+// SetAnimationPolicy sets what happens to animations triggered on channel
+// while another one plays, PolicyEnqueue by default
+func (r *RoboEyes) SetAnimationPolicy(channel Channel, policy Policy) {
+	if channel < channelCount {
+		r.channels[channel].policy = policy
+	}
+}
+
+// SetAnimationPriority sets the priority of an animation, from 0 to 255.
+// All animations start at 128; a startle always takes precedence.
+func (r *RoboEyes) SetAnimationPriority(animation Animation, priority uint8) {
+	if int(animation) < len(r.priorities) {
+		r.priorities[animation] = priority
+	}
+}
+
+// Playing returns the animation playing on channel, if any
+func (r *RoboEyes) Playing(channel Channel) (Animation, bool) {
+	if channel >= channelCount {
+		return 0, false
+	}
+	ch := &r.channels[channel]
+	return ch.playing.animation, ch.busy
+}
+
+// channel returns the channel of the animation, startles take them all
+func (a Animation) channel() (Channel, bool) {
+	switch a {
+	case AnimationEyeRoll:
+		return ChannelGaze, true
+	case AnimationWink, AnimationSquint:
+		return ChannelLids, true
+	case AnimationLaugh, AnimationConfused, AnimationNod, AnimationShake:
+		return ChannelShake, true
+	}
+	return 0, false
+}
+
+// schedule plays, queues or drops a triggered animation. While a startle
+// plays, animations wait unless their channel ignores them.
+func (r *RoboEyes) schedule(call animationCall) {
+	channel, ok := call.animation.channel()
+	if !ok {
+		return
+	}
+	call.priority = r.priorities[call.animation]
+	ch := &r.channels[channel]
+	switch {
+	case r.startle:
+		if ch.policy != PolicyIgnore {
+			ch.enqueue(call)
+		}
+	case !ch.busy:
+		r.startAnimation(call)
+	case call.priority > ch.playing.priority || ch.policy == PolicyReplace:
+		r.stopAnimation(ch.playing.animation)
+		if ch.busy {
+			// The event handler of the interrupted animation started another
+			ch.enqueue(call)
+		} else {
+			r.startAnimation(call)
+		}
+	case ch.policy == PolicyEnqueue:
+		ch.enqueue(call)
+	}
+}
+
+// enqueue inserts call after the waiting animations of the same or a higher
+// priority, it is dropped when the queue is full
+func (ch *animationChannel) enqueue(call animationCall) {
+	if ch.queued == len(ch.queue) {
+		return
+	}
+	i := ch.queued
+	for i > 0 && ch.queue[i-1].priority < call.priority {
+		ch.queue[i] = ch.queue[i-1]
+		i--
+	}
+	ch.queue[i] = call
+	ch.queued++
+}
+
+// runQueues starts the first waiting animation of every free channel
+func (r *RoboEyes) runQueues() {
+	if r.startle {
+		return
+	}
+	for i := range r.channels {
+		ch := &r.channels[i]
+		if ch.busy || ch.queued == 0 {
+			continue
+		}
+		call := ch.queue[0]
+		copy(ch.queue[:], ch.queue[1:ch.queued])
+		ch.queued--
+		r.startAnimation(call)
+	}
+}
+
+// animating reports whether a triggered animation or a startle is playing
+func (r *RoboEyes) animating() bool {
+	for i := range r.channels {
+		if r.channels[i].busy {
+			return true
+		}
+	}
+	return r.startle
+}
+
+// startAnimation plays call on its channel from the next frame
+func (r *RoboEyes) startAnimation(call animationCall) {
+	channel, _ := call.animation.channel()
+	r.channels[channel].playing = call
+	r.channels[channel].busy = true
+
+	switch call.animation {
+	case AnimationLaugh:
+		r.laugh = true
+	case AnimationConfused:
+		r.confused = true
+	case AnimationWink:
+		r.wink = true
+		r.winkLeft = call.left
+		r.winkAnimationDuration = call.duration
+	case AnimationSquint:
+		r.squint = true
+		r.squintAnimationDuration = call.duration
+	case AnimationEyeRoll:
+		r.eyeRoll = true
+		r.eyeRollAnimationDuration = call.duration
+	case AnimationNod:
+		r.nod.start(call.count, call.amplitude, call.period)
+	case AnimationShake:
+		r.shake.start(call.count, call.amplitude, call.period)
+	}
+}
+
+// stopAnimation interrupts a playing animation and undoes what it changed:
+// flickers stop, a winking eye opens, squinting eyes and the gaze of an eye
+// roll return to where they were
+func (r *RoboEyes) stopAnimation(a Animation) {
+	switch a {
+	case AnimationLaugh:
+		r.laugh, r.laughToggle = false, true
+		r.setVFlicker(false, 0)
+	case AnimationConfused:
+		r.confused, r.confusedToggle = false, true
+		r.setHFlicker(false, 0)
+	case AnimationWink:
+		if !r.winkToggle {
+			left := r.winkLeft || r.cyclops
+			r.openEyes(left, !left)
+		}
+		r.wink, r.winkToggle = false, true
+	case AnimationSquint:
+		r.squint, r.squintToggle = false, true
+		if r.eyeLheightNext != 1 {
+			r.eyeLheightNext = r.eyeLheightDefault
+		}
+		if r.eyeRheightNext != 1 {
+			r.eyeRheightNext = r.eyeRheightDefault
+		}
+	case AnimationEyeRoll:
+		if !r.eyeRollToggle {
+			r.eyeLxNext, r.eyeLyNext = r.eyeRollFromX, r.eyeRollFromY
+		}
+		r.eyeRoll, r.eyeRollToggle = false, true
+	case AnimationNod:
+		r.nod.active = false
+	case AnimationShake:
+		r.shake.active = false
+	}
+	r.animationDone(a, true)
+}
@@ -0,0 +1,160 @@
+package roboeyestinygo
+
+import "testing"
+
+// recordDone returns open eyes whose finished animations are appended to
+// the returned slice
+func recordDone() (*RoboEyes, *[]Event) {
+	var done []Event
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	r.Open()
+	step(r, 5)
+	r.OnEvent(func(e Event) {
+		if e.Type == EventAnimationDone {
+			done = append(done, e)
+		}
+	})
+	return r, &done
+}
+
+func checkDone(t *testing.T, done []Event, want ...Event) {
+	t.Helper()
+	if len(done) != len(want) {
+		t.Fatalf("done %+v, want %+v", done, want)
+	}
+	for i := range want {
+		if done[i].Animation != want[i].Animation || done[i].Interrupted != want[i].Interrupted {
+			t.Errorf("done %d: %+v, want %+v", i, done[i], want[i])
+		}
+	}
+}
+
+func TestSchedulerEnqueue(t *testing.T) {
+	r, done := recordDone()
+	r.AnimLaugh()
+	r.AnimConfused()
+	r.AnimWink(true)
+	if a, ok := r.Playing(ChannelShake); !ok || a != AnimationLaugh || r.channels[ChannelShake].queued != 1 {
+		t.Fatalf("shake channel plays %v %v with %d queued", a, ok, r.channels[ChannelShake].queued)
+	}
+	if a, ok := r.Playing(ChannelLids); !ok || a != AnimationWink {
+		t.Fatalf("lids channel plays %v %v", a, ok)
+	}
+	step(r, 150)
+	checkDone(t, *done,
+		Event{Animation: AnimationWink},
+		Event{Animation: AnimationLaugh},
+		Event{Animation: AnimationConfused})
+	if r.animating() {
+		t.Error("still animating")
+	}
+}
+
+func TestSchedulerQueueLimit(t *testing.T) {
+	r, _ := recordDone()
+	for i := 0; i < maxQueuedAnimations+3; i++ {
+		r.AnimLaugh()
+	}
+	if n := r.channels[ChannelShake].queued; n != maxQueuedAnimations {
+		t.Fatalf("%d queued, want %d", n, maxQueuedAnimations)
+	}
+}
+
+func TestSchedulerReplace(t *testing.T) {
+	r, done := recordDone()
+	r.SetAnimationPolicy(ChannelShake, PolicyReplace)
+	r.AnimLaugh()
+	step(r, 2)
+	r.AnimNod(2, 3, 300)
+	step(r, 60)
+	checkDone(t, *done,
+		Event{Animation: AnimationLaugh, Interrupted: true},
+		Event{Animation: AnimationNod})
+}
+
+func TestSchedulerIgnoreAndPriority(t *testing.T) {
+	r, done := recordDone()
+	r.SetAnimationPolicy(ChannelShake, PolicyIgnore)
+	r.AnimLaugh()
+	r.AnimConfused()
+	if n := r.channels[ChannelShake].queued; n != 0 {
+		t.Fatalf("%d queued, want ignored", n)
+	}
+	r.SetAnimationPriority(AnimationShake, 200)
+	r.AnimShake(1, 3, 200)
+	step(r, 100)
+	checkDone(t, *done,
+		Event{Animation: AnimationLaugh, Interrupted: true},
+		Event{Animation: AnimationShake})
+}
+
+func TestSchedulerStartleHoldsQueue(t *testing.T) {
+	r, done := recordDone()
+	r.AnimLaugh()
+	r.AnimStartle(200)
+	r.AnimConfused()
+	if _, ok := r.Playing(ChannelShake); ok {
+		t.Fatal("shake channel plays during a startle")
+	}
+	step(r, 200)
+	checkDone(t, *done,
+		Event{Animation: AnimationLaugh, Interrupted: true},
+		Event{Animation: AnimationStartle},
+		Event{Animation: AnimationConfused})
+}
+
+func TestPlayingInvalidChannel(t *testing.T) {
+	r, _ := recordDone()
+	if _, ok := r.Playing(channelCount); ok {
+		t.Error("invalid channel reported playing")
+	}
+}
+
+func TestSchedulerReentrantHandler(t *testing.T) {
+	r, done := recordDone()
+	r.OnEvent(func(e Event) {
+		if e.Type == EventAnimationDone {
+			*done = append(*done, e)
+			if e.Animation == AnimationConfused {
+				r.AnimLaugh()
+			}
+		}
+	})
+	r.SetAnimationPolicy(ChannelShake, PolicyReplace)
+	r.AnimConfused()
+	step(r, 2)
+	r.AnimNod(2, 3, 300)
+	if a, _ := r.Playing(ChannelShake); a != AnimationLaugh || !r.laugh || r.nod.active {
+		t.Fatalf("playing %v, laugh %v nod %v", a, r.laugh, r.nod.active)
+	}
+	step(r, 150)
+	checkDone(t, *done,
+		Event{Animation: AnimationConfused, Interrupted: true},
+		Event{Animation: AnimationLaugh},
+		Event{Animation: AnimationNod})
+	if r.animating() {
+		t.Error("still animating")
+	}
+}
+
+func TestStartleReentrantHandler(t *testing.T) {
+	r, done := recordDone()
+	r.OnEvent(func(e Event) {
+		if e.Type == EventAnimationDone {
+			*done = append(*done, e)
+			if e.Animation == AnimationLaugh {
+				r.AnimConfused()
+			}
+		}
+	})
+	r.AnimLaugh()
+	r.AnimStartle(200)
+	if _, ok := r.Playing(ChannelShake); ok || r.confused {
+		t.Fatal("animation started during a startle")
+	}
+	step(r, 200)
+	checkDone(t, *done,
+		Event{Animation: AnimationLaugh, Interrupted: true},
+		Event{Animation: AnimationStartle},
+		Event{Animation: AnimationConfused})
+}
@@ -0,0 +1,158 @@
+package roboeyestinygo
+
+// sleepState is a step of the sleep state machine
+type sleepState byte
+
+const (
+	sleepAwake   sleepState = iota
+	sleepFalling            // Tired eyes blinking slowly, then closing
+	sleepAsleep             // Eyes closed, blinking and idle mode suspended
+	sleepWaking             // Groggy eyes opening halfway, blinking, then opening
+)
+
+// Timing of the sleep sequences in milliseconds
+const (
+	slowBlinkDuration = 1200 // One slow blink while falling asleep, also the final closing
+	slowBlinks        = 2
+	wakeUpOpening     = 1000 // Groggy half opening before the wake-up blink
+	wakeUpDuration    = 1600
+	zzzStepDuration   = 100
+	zzzSteps          = 24 // Steps for a Zzz letter to rise from the eyes to its top
+)
+
+// Fixed point unit of eye openness during the sleep sequences
+const openUnit = 256
+
+// SetSleepTimeout makes the eyes fall asleep after seconds without any
+// interaction, 0 disables it. Interactions are the calls controlling the
+// eyes: expression, gaze, eyelids, animations, behaviors, shape and colors;
+// any of them also wakes the eyes up.
+func (r *RoboEyes) SetSleepTimeout(seconds uint32) {
+	r.sleepTimeout = seconds * 1000
+	r.lastInteraction = r.millis()
+}
+
+// SetZzz enables/disables Zzz letters rising above the sleeping eyes
+func (r *RoboEyes) SetZzz(active bool) {
+	r.zzz = active
+}
+
+// Sleep makes the eyes fall asleep: they get tired, blink slowly and close.
+// Blinking and idle mode are suspended until they wake up.
+func (r *RoboEyes) Sleep() {
+	if r.sleep == sleepAwake || r.sleep == sleepWaking {
+		r.sleep = sleepFalling
+		r.sleepTimer = r.millis()
+	}
+}
+
+// Wake plays the groggy wake-up sequence if the eyes are falling asleep or
+// sleeping
+func (r *RoboEyes) Wake() {
+	r.lastInteraction = r.millis()
+	if r.sleep == sleepFalling || r.sleep == sleepAsleep {
+		r.sleep = sleepWaking
+		r.sleepTimer = r.lastInteraction
+		r.closeEyes(true, true)
+	}
+}
+
+// Sleeping reports whether the eyes are falling asleep or sleeping
+func (r *RoboEyes) Sleeping() bool {
+	return r.sleep == sleepFalling || r.sleep == sleepAsleep
+}
+
+// interact records a control call: it restarts the inactivity timeout and
+// wakes the eyes up
+func (r *RoboEyes) interact() {
+	r.Wake()
+}
+
+// animateSleep runs the sleep state machine
+func (r *RoboEyes) animateSleep(currentTime uint32) {
+	r.zzzStep = 0
+	elapsed := currentTime - r.sleepTimer
+	switch r.sleep {
+	case sleepAwake:
+		if r.sleepTimeout > 0 && currentTime-r.lastInteraction >= r.sleepTimeout {
+			r.sleep = sleepFalling
+			r.sleepTimer = currentTime
+		}
+		return
+
+	case sleepFalling:
+		r.drowsy = true
+		blink := elapsed / slowBlinkDuration
+		phase := int32(elapsed%slowBlinkDuration) * openUnit / slowBlinkDuration
+		switch {
+		case blink < slowBlinks:
+			// Close and reopen
+			r.setOpenness(max(openUnit-2*phase, 2*phase-openUnit))
+		case blink == slowBlinks:
+			// Close for good
+			r.setOpenness(openUnit - phase)
+		default:
+			r.closeEyes(true, true)
+			r.sleep = sleepAsleep
+			r.sleepTimer = currentTime
+			r.emit(Event{Type: EventFellAsleep})
+		}
+
+	case sleepAsleep:
+		if r.zzz {
+			r.zzzStep = int16(elapsed/zzzStepDuration%zzzSteps) + 1
+		}
+
+	case sleepWaking:
+		switch {
+		case elapsed < wakeUpOpening:
+			r.setOpenness(int32(elapsed) * openUnit / 2 / wakeUpOpening)
+		case elapsed < wakeUpDuration:
+			// Blink once, the eyes then open fully
+			if !r.eyeL_open {
+				r.blink(true, true)
+			}
+		default:
+			r.drowsy = false
+			r.sleep = sleepAwake
+			r.emit(Event{Type: EventWokeUp})
+		}
+	}
+}
+
+// setOpenness sets the height both eyes move to, from closed at 0 to their
+// default height at openUnit
+func (r *RoboEyes) setOpenness(open int32) {
+	r.eyeLheightNext = 1 + int16(int32(r.eyeLheightDefault-1)*open/openUnit)
+	r.eyeRheightNext = 1 + int16(int32(r.eyeRheightDefault-1)*open/openUnit)
+}
+
+// drawZzz draws three Z letters rising and growing from the top right of the
+// eyes, each one a third of the way behind the previous one
+func (r *RoboEyes) drawZzz(step int16) {
+	x, y := r.eyeLx+r.eyeLwidthCurrent, r.eyeLy
+	if !r.cyclops {
+		x = r.eyeRx + r.eyeRwidthCurrent
+	}
+	x -= r.Scaled(4)
+	y -= r.Scaled(4)
+	thickness := max(r.Scaled(1), 1)
+
+	for i := int16(0); i < 3; i++ {
+		rise := (step - 1 + i*zzzSteps/3) % zzzSteps
+		size := r.Scaled(3) + rise*r.Scaled(5)/zzzSteps
+		zx := x + rise*r.Scaled(12)/zzzSteps
+		zy := y - size - rise*r.Scaled(16)/zzzSteps
+		r.drawZ(zx, zy, max(size, thickness+2), thickness)
+	}
+}
+
+// drawZ draws a Z letter of size x size pixels at (x, y)
+func (r *RoboEyes) drawZ(x, y, size, thickness int16) {
+	r.fillRect(x, y, size, thickness, r.eyesColor)
+	r.fillRect(x, y+size-thickness, size, thickness, r.eyesColor)
+	for j := thickness; j < size-thickness; j++ {
+		// Diagonal from the top right to the bottom left corner
+		r.fillRect(x+(size-1-thickness)*(size-1-j)/(size-1), y+j, thickness, 1, r.eyesColor)
+	}
+}
@@ -0,0 +1,87 @@
+package roboeyestinygo
+
+import (
+	"image/color"
+	"testing"
+)
+
+// asleep returns eyes that fell asleep
+func asleep(t *testing.T) *RoboEyes {
+	t.Helper()
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	r.Open()
+	r.Sleep()
+	step(r, 200)
+	if r.sleep != sleepAsleep || r.eyeL_open || r.eyeR_open {
+		t.Fatalf("sleep state %d, eyes open %v %v", r.sleep, r.eyeL_open, r.eyeR_open)
+	}
+	return r
+}
+
+func TestSleepAndWake(t *testing.T) {
+	r := asleep(t)
+	if !r.Sleeping() {
+		t.Fatal("not sleeping")
+	}
+	r.Wake()
+	step(r, 100)
+	if r.Sleeping() || r.sleep != sleepAwake || !r.eyeL_open || !r.eyeR_open {
+		t.Fatalf("sleep state %d after waking", r.sleep)
+	}
+}
+
+func TestControlCallsWakeTheEyes(t *testing.T) {
+	for name, call := range map[string]func(r *RoboEyes){
+		"SetMood":         func(r *RoboEyes) { r.SetMood(MoodHappy) },
+		"SetDirection":    func(r *RoboEyes) { r.SetDirection(DirE) },
+		"Open":            func(r *RoboEyes) { r.Open() },
+		"AnimWink":        func(r *RoboEyes) { r.AnimWink(true) },
+		"SetAutoBlinker":  func(r *RoboEyes) { r.SetAutoBlinker(true) },
+		"SetIdleMode":     func(r *RoboEyes) { r.SetIdleMode(true) },
+		"SetCuriosity":    func(r *RoboEyes) { r.SetCuriosity(true) },
+		"SetCyclops":      func(r *RoboEyes) { r.SetCyclops(true) },
+		"SetHFlicker":     func(r *RoboEyes) { r.SetHFlicker(true, 2) },
+		"SetVFlicker":     func(r *RoboEyes) { r.SetVFlicker(true, 2) },
+		"SetSize":         func(r *RoboEyes) { r.SetSize(30, 30) },
+		"SetBorderRadius": func(r *RoboEyes) { r.SetBorderRadius(4, 4) },
+		"SetSpaceBetween": func(r *RoboEyes) { r.SetSpaceBetween(8) },
+		"SetColors":       func(r *RoboEyes) { r.SetColors(white, color.RGBA{0, 0, 0, 255}) },
+		"SetMoodColors":   func(r *RoboEyes) { r.SetMoodColors(true) },
+		"SetFillStyle":    func(r *RoboEyes) { r.SetFillStyle(FillStyle{}, FillStyle{}) },
+	} {
+		r := asleep(t)
+		call(r)
+		if r.Sleeping() {
+			t.Errorf("%s: still sleeping", name)
+		}
+	}
+}
+
+func TestSleepTimeout(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	r.Open()
+	r.SetSleepTimeout(2)
+	// Animations flicker the eyes internally, that is no interaction
+	r.AnimLaugh()
+	r.AnimConfused()
+	step(r, 120)
+	if r.sleep == sleepAwake {
+		t.Fatal("eyes still awake after the timeout")
+	}
+	r.SetCuriosity(false)
+	if r.Sleeping() {
+		t.Fatal("control call did not wake the eyes")
+	}
+}
+
+func TestStartleWakesAtOnce(t *testing.T) {
+	r := asleep(t)
+	r.AnimStartle(255)
+	if r.sleep != sleepAwake {
+		t.Fatalf("sleep state %d after a startle", r.sleep)
+	}
+	step(r, 2)
+	if !r.eyeL_open || !r.eyeR_open {
+		t.Fatal("startled eyes closed")
+	}
+}
@@ -0,0 +1,114 @@
+package roboeyestinygo
+
+// Timing of the startle reaction in milliseconds
+const (
+	startleShiver        = 300 // Wide eyes shivering before the first blink
+	startleBlinkInterval = 200 // Between the rapid blinks, and after the last one
+)
+
+// AnimStartle makes the eyes react at once to a sudden event such as a loud
+// noise or a bump, with intensity from 0 (a flinch) to 255 (a fright). It
+// interrupts the animations playing and wakes sleeping eyes without the
+// groggy sequence: the eyes open wide, shiver and blink rapidly, then go
+// back to the mood and gaze they had. Startles are ignored while one plays
+// and during the cool-down set with SetStartleCooldown.
+func (r *RoboEyes) AnimStartle(intensity uint8) {
+	currentTime := r.millis()
+	if r.startle || currentTime < r.startleCooldownEnd {
+		return
+	}
+	r.lastInteraction = currentTime
+
+	// Animations triggered by the handlers of the interrupted ones wait for
+	// the end of the startle. Remember where to return, once an eye roll
+	// gave the gaze back.
+	r.startle = true
+	r.interruptAnimations()
+	r.startleMood = r.mood
+	r.startleDirection = r.direction
+	r.startleX, r.startleY = r.eyeLxNext, r.eyeLyNext
+
+	r.startleTimer = currentTime
+	r.startleIntensity = intensity
+	r.startleBlinked = 0
+	r.startleCooldownEnd = currentTime + r.startleDuration() + r.startleCooldown
+
+	// Surprised eyes, without eyelids
+	r.setMood(MoodDefault)
+	r.openEyes(true, true)
+	r.setVFlicker(true, r.Scaled(1+int16(intensity)/128))
+}
+
+// SetStartleCooldown sets how long in milliseconds after a startle reaction
+// ends new startles are ignored
+func (r *RoboEyes) SetStartleCooldown(cooldown uint32) {
+	r.startleCooldown = cooldown
+}
+
+// interruptAnimations stops the playing animations and sleep, waiting
+// animations start after the startle
+func (r *RoboEyes) interruptAnimations() {
+	for i := range r.channels {
+		if ch := &r.channels[i]; ch.busy {
+			r.stopAnimation(ch.playing.animation)
+		}
+	}
+	r.setVFlicker(false, 0)
+	r.setHFlicker(false, 0)
+
+	if r.sleep != sleepAwake {
+		r.sleep = sleepAwake
+		r.drowsy = false
+		r.emit(Event{Type: EventWokeUp})
+	}
+}
+
+// startleBlinks returns the number of rapid blinks, two to three
+func (r *RoboEyes) startleBlinks() uint32 {
+	return 2 + uint32(r.startleIntensity)/128
+}
+
+// startleDuration returns how long the startle reaction lasts
+func (r *RoboEyes) startleDuration() uint32 {
+	return startleShiver + (r.startleBlinks()+1)*startleBlinkInterval
+}
+
+// animateStartle keeps the eyes wide open, stops the shiver after a moment,
+// blinks rapidly and restores mood and gaze at the end
+func (r *RoboEyes) animateStartle(currentTime uint32) {
+	if !r.startle {
+		return
+	}
+	elapsed := currentTime - r.startleTimer
+	if elapsed >= r.startleDuration() {
+		r.startle = false
+		r.setMood(r.startleMood)
+		r.direction = r.startleDirection
+		r.eyeLxNext, r.eyeLyNext = r.startleX, r.startleY
+		r.eyeLheightNext = r.eyeLheightDefault
+		r.eyeRheightNext = r.eyeRheightDefault
+		r.animationDone(AnimationStartle, false)
+		r.gazeTargetChanged()
+		return
+	}
+
+	if elapsed >= startleShiver {
+		r.setVFlicker(false, 0)
+		// One blink at the start of each interval
+		if r.startleBlinked < r.startleBlinks() &&
+			elapsed >= startleShiver+r.startleBlinked*startleBlinkInterval {
+			r.blink(true, true)
+			r.startleBlinked++
+		}
+	}
+
+	// Up to a quarter taller, closed eyes are left to the blink
+	left := r.eyeLheightDefault + int16(int32(r.eyeLheightDefault)*int32(r.startleIntensity)/1020)
+	right := r.eyeRheightDefault + int16(int32(r.eyeRheightDefault)*int32(r.startleIntensity)/1020)
+	if r.eyeLheightNext != 1 {
+		r.eyeLheightNext = left
+	}
+	if r.eyeRheightNext != 1 {
+		r.eyeRheightNext = right
+	}
+}
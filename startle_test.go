@@ -0,0 +1,64 @@
+package roboeyestinygo
+
+import "testing"
+
+func TestStartleRestoresMoodAndGaze(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	r.Open()
+	r.SetMood(MoodAngry)
+	r.SetDirection(DirE)
+	step(r, 30)
+	x, y := r.eyeLxNext, r.eyeLyNext
+	r.AnimLaugh()
+	step(r, 3)
+	r.AnimStartle(255)
+	if r.laugh || r.mood != MoodDefault {
+		t.Fatalf("laugh %v mood %d while startled", r.laugh, r.mood)
+	}
+	step(r, 5)
+	if r.eyeLheightCurrent <= r.eyeLheightDefault {
+		t.Errorf("startled eyes %d high, not wider than %d", r.eyeLheightCurrent, r.eyeLheightDefault)
+	}
+	step(r, 60)
+	if r.startle || r.mood != MoodAngry || r.direction != DirE || r.eyeLxNext != x || r.eyeLyNext != y {
+		t.Fatalf("after the startle: mood %d direction %d gaze %d,%d", r.mood, r.direction, r.eyeLxNext, r.eyeLyNext)
+	}
+}
+
+func TestStartleBlinks(t *testing.T) {
+	for _, c := range []struct {
+		intensity uint8
+		blinks    uint32
+	}{{0, 2}, {127, 2}, {128, 3}, {255, 3}} {
+		r := newTestEyes(newTestDevice(128, 64), 50)
+		r.Open()
+		r.AnimStartle(c.intensity)
+		step(r, 60)
+		if r.startleBlinked != c.blinks {
+			t.Errorf("intensity %d: %d blinks, want %d", c.intensity, r.startleBlinked, c.blinks)
+		}
+	}
+}
+
+func TestStartleCooldown(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	r.Open()
+	r.SetStartleCooldown(1000)
+	r.AnimStartle(100)
+	start := r.startleTimer
+	step(r, 5)
+	r.AnimStartle(255) // Playing
+	if r.startleIntensity != 100 {
+		t.Fatal("startle restarted while playing")
+	}
+	step(r, 50)
+	r.AnimStartle(255) // Cooling down
+	if r.startle {
+		t.Fatal("startle during the cool-down")
+	}
+	step(r, 50)
+	r.AnimStartle(255)
+	if !r.startle || r.startleTimer == start {
+		t.Fatal("startle ignored after the cool-down")
+	}
+}
@@ -0,0 +1,91 @@
+package roboeyestinygo
+
+// State is a snapshot of what the eyes are doing, as returned by Snapshot and
+// accepted by Restore. Geometry tunables are not part of it.
+type State struct {
+	Mood      Mood
+	Direction Direction // Last direction set with SetDirection
+	GazeX     int16     // Target X position of the left eye
+	GazeY     int16     // Target Y position of the eyes
+
+	LeftOpen  bool
+	RightOpen bool
+	Curious   bool
+	Cyclops   bool
+
+	AutoBlinker bool
+	Idle        bool
+	Laughing    bool
+	Confused    bool
+
+	HFlicker          bool
+	HFlickerAmplitude int16
+	VFlicker          bool
+	VFlickerAmplitude int16
+}
+
+// Snapshot returns the current state of the eyes
+func (r *RoboEyes) Snapshot() State {
+	return State{
+		Mood:              r.mood,
+		Direction:         r.direction,
+		GazeX:             r.eyeLxNext,
+		GazeY:             r.eyeLyNext,
+		LeftOpen:          r.eyeL_open,
+		RightOpen:         r.eyeR_open,
+		Curious:           r.curious,
+		Cyclops:           r.cyclops,
+		AutoBlinker:       r.autoblinker,
+		Idle:              r.idle,
+		Laughing:          r.laugh,
+		Confused:          r.confused,
+		HFlicker:          r.hFlicker,
+		HFlickerAmplitude: r.hFlickerAmplitude,
+		VFlicker:          r.vFlicker,
+		VFlickerAmplitude: r.vFlickerAmplitude,
+	}
+}
+
+// Restore applies a state previously returned by Snapshot.
+// The eyes move, open and close with the usual smooth transitions and
+// running laugh/confused animations restart from the beginning.
+// Unknown directions restore as DirCenter and the gaze is kept on screen,
+// so a corrupted state still leaves the eyes usable.
+func (r *RoboEyes) Restore(s State) {
+	r.SetMood(s.Mood)
+
+	r.CloseEyes(!s.LeftOpen, !s.RightOpen)
+	r.OpenEyes(s.LeftOpen, s.RightOpen)
+	r.SetCuriosity(s.Curious)
+	r.SetCyclops(s.Cyclops)
+
+	r.direction = s.Direction
+	if r.direction > DirNW {
+		r.direction = DirCenter
+	}
+	r.eyeLxNext = max(0, s.GazeX)
+	if maxX := r.GetScreenConstraintX(); r.eyeLxNext > maxX {
+		r.eyeLxNext = max(0, maxX)
+	}
+	r.eyeLyNext = max(0, s.GazeY)
+	if maxY := r.GetScreenConstraintY(); r.eyeLyNext > maxY {
+		r.eyeLyNext = max(0, maxY)
+	}
+
+	r.SetAutoBlinker(s.AutoBlinker)
+	r.SetIdleMode(s.Idle)
+	for _, a := range [...]Animation{AnimationLaugh, AnimationConfused} {
+		if playing, busy := r.Playing(ChannelShake); busy && playing == a {
+			r.stopAnimation(a)
+		}
+	}
+	if s.Laughing {
+		r.schedule(animationCall{animation: AnimationLaugh})
+	}
+	if s.Confused {
+		r.schedule(animationCall{animation: AnimationConfused})
+	}
+
+	r.SetHFlicker(s.HFlicker, s.HFlickerAmplitude)
+	r.SetVFlicker(s.VFlicker, s.VFlickerAmplitude)
+}
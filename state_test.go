@@ -0,0 +1,77 @@
+package roboeyestinygo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	a := newTestEyes(newTestDevice(128, 64), 50)
+	a.Open()
+	a.SetMood(MoodHappy)
+	a.SetDirection(DirSE)
+	a.CloseEyes(true, false)
+	a.SetCuriosity(true)
+	a.SetAutoBlinker(true)
+	a.SetHFlicker(true, 3)
+	a.AnimLaugh()
+	step(a, 5)
+	s := a.Snapshot()
+
+	b := newTestEyes(newTestDevice(128, 64), 50)
+	b.Restore(s)
+	if got := b.Snapshot(); got != s {
+		t.Fatalf("restored %+v\nwant %+v", got, s)
+	}
+
+	// Eyes move to the restored state with the usual transitions, give or
+	// take the flicker of the laugh
+	step(b, 60)
+	dx, dy := b.eyeLx-s.GazeX, b.eyeLy-s.GazeY
+	if dx < -3 || dx > 3 || dy < -5 || dy > 5 || b.mood != MoodHappy {
+		t.Fatalf("left eye at %d,%d, want %d,%d", b.eyeLx, b.eyeLy, s.GazeX, s.GazeY)
+	}
+}
+
+func TestRestoreRestartsAnimations(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	r.Open()
+	r.AnimLaugh()
+	step(r, 20)
+	s := r.Snapshot()
+	if !s.Laughing {
+		t.Fatal("not laughing")
+	}
+	start := r.laughAnimationTimer
+	step(r, 1)
+	r.Restore(s)
+	step(r, 1)
+	if !r.laugh || r.laughAnimationTimer == start {
+		t.Fatalf("laugh %v started at %d, first start %d", r.laugh, r.laughAnimationTimer, start)
+	}
+
+	s.Laughing = false
+	r.Restore(s)
+	if r.laugh {
+		t.Fatal("laugh kept playing")
+	}
+}
+
+func TestRestoreCorruptedState(t *testing.T) {
+	r := newTestEyes(newTestDevice(128, 64), 50)
+	r.Restore(State{Mood: 9, Direction: 42, GazeX: 30000, GazeY: -200})
+	s := r.Snapshot()
+	if s.Mood != MoodDefault || s.Direction != DirCenter {
+		t.Fatalf("mood %d direction %d", s.Mood, s.Direction)
+	}
+	if s.GazeX != r.GetScreenConstraintX() || s.GazeY != 0 {
+		t.Fatalf("gaze %d,%d, want %d,0", s.GazeX, s.GazeY, r.GetScreenConstraintX())
+	}
+	if reply := NewTextProtocol(r).Execute("STATE"); !strings.Contains(reply, " dir=c ") {
+		t.Fatalf("STATE reply %q", reply)
+	}
+	step(r, 40)
+	if r.eyeLx < 0 || r.eyeLx > r.GetScreenConstraintX() || r.eyeLy < 0 || r.eyeLy > r.GetScreenConstraintY() {
+		t.Fatalf("left eye at %d,%d off screen", r.eyeLx, r.eyeLy)
+	}
+}
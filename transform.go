@@ -0,0 +1,153 @@
+package roboeyestinygo
+
+import "image/color"
+
+// Rotation turns the picture clockwise on the panel
+type Rotation byte
+
+const (
+	Rotate0 Rotation = iota
+	Rotate90
+	Rotate180
+	Rotate270
+)
+
+// SetRotation sets how the panel is mounted. With 90 and 270 degrees the
+// screen width and height are swapped and the eyes are laid out again, so
+// that directions keep their meaning on the mounted panel. In dual mode
+// each display is rotated on its own and keeps its eye.
+func (r *RoboEyes) SetRotation(rotation Rotation) {
+	if d, ok := r.device.(*dualDevice); ok {
+		r.orientDual(d, rotation%4, d.mirrorX, d.mirrorY)
+		return
+	}
+	r.rotation = rotation % 4
+	r.applyTransform()
+}
+
+// SetMirror flips the picture horizontally (x) and/or vertically (y) on the
+// panel, after rotation. Use it for modules that are wired mirrored. In dual
+// mode each display is flipped on its own, the eyes do not swap displays.
+func (r *RoboEyes) SetMirror(x, y bool) {
+	if d, ok := r.device.(*dualDevice); ok {
+		r.orientDual(d, d.rotation, x, y)
+		return
+	}
+	r.mirrorX = x
+	r.mirrorY = y
+	r.applyTransform()
+}
+
+// Size returns the screen dimensions the eyes are laid out on,
+// i.e. the panel dimensions after rotation
+func (r *RoboEyes) Size() (width, height int16) {
+	return r.screenWidth, r.screenHeight
+}
+
+// applyTransform updates the screen dimensions after a rotation or viewport
+// change and forces a full redraw
+func (r *RoboEyes) applyTransform() {
+	width, height := r.panelWidth, r.panelHeight
+	if r.transposed() {
+		width, height = height, width
+	}
+	display := region{0, 0, width, height}
+	view := display
+	if r.viewport.width > 0 && r.viewport.height > 0 {
+		view = r.viewport.clip(width, height)
+	}
+	r.offsetX, r.offsetY = view.x, view.y
+	r.fullScreen = view == display
+	r.transformed = r.rotation != Rotate0 || r.mirrorX || r.mirrorY || view.x != 0 || view.y != 0
+
+	if view.width != r.screenWidth || view.height != r.screenHeight {
+		r.screenWidth = view.width
+		r.screenHeight = view.height
+		if r.autoScale {
+			r.applyScale()
+		} else {
+			// Move the eyes to their position on the new screen
+			r.setDirection(r.direction)
+		}
+	}
+	r.frameValid = false
+}
+
+// transposed reports whether screen rows are panel columns
+func (r *RoboEyes) transposed() bool {
+	return r.rotation == Rotate90 || r.rotation == Rotate270
+}
+
+// toPanel maps screen coordinates to panel coordinates: viewport offset,
+// then rotation, then mirroring
+func (r *RoboEyes) toPanel(x, y int16) (int16, int16) {
+	if !r.transformed {
+		return x, y
+	}
+	return orient(x+r.offsetX, y+r.offsetY, r.panelWidth, r.panelHeight, r.rotation, r.mirrorX, r.mirrorY)
+}
+
+// orient rotates, then mirrors a point of a panel of width x height pixels
+func orient(x, y, width, height int16, rotation Rotation, mirrorX, mirrorY bool) (int16, int16) {
+	switch rotation {
+	case Rotate90:
+		x, y = width-1-y, x
+	case Rotate180:
+		x, y = width-1-x, height-1-y
+	case Rotate270:
+		x, y = y, height-1-x
+	}
+	if mirrorX {
+		x = width - 1 - x
+	}
+	if mirrorY {
+		y = height - 1 - y
+	}
+	return x, y
+}
+
+// toPanelRect maps a screen rectangle to the panel rectangle covering the
+// same pixels, rotations and mirrors keep rectangles rectangular
+func (r *RoboEyes) toPanelRect(x, y, width, height int16) (int16, int16, int16, int16) {
+	if !r.transformed {
+		return x, y, width, height
+	}
+	x0, y0 := r.toPanel(x, y)
+	x1, y1 := r.toPanel(x+width-1, y+height-1)
+	return cornerRect(x0, y0, x1, y1)
+}
+
+// cornerRect returns the rectangle with opposite corners x0,y0 and x1,y1
+func cornerRect(x0, y0, x1, y1 int16) (int16, int16, int16, int16) {
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+	return x0, y0, x1 - x0 + 1, y1 - y0 + 1
+}
+
+// devicePixel sets a pixel given in screen coordinates on the panel
+func (r *RoboEyes) devicePixel(x, y int16, c color.RGBA) {
+	x, y = r.toPanel(x, y)
+	r.device.SetPixel(x, y, c)
+}
+
+// deviceRect fills a rectangle given in screen coordinates on the panel,
+// the device must implement RectangleFiller
+func (r *RoboEyes) deviceRect(x, y, width, height int16, c color.RGBA) {
+	x, y, width, height = r.toPanelRect(x, y, width, height)
+	r.rectFill.FillRectangle(x, y, width, height, c)
+}
+
+// deviceHLine draws pixels x0 to x1 of screen row y on the panel, the device
+// must implement HLineDrawer and the screen must not be transposed
+func (r *RoboEyes) deviceHLine(x0, x1, y int16, c color.RGBA) {
+	x0, py := r.toPanel(x0, y)
+	x1, _ = r.toPanel(x1, y)
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	r.hLine.DrawFastHLine(x0, x1, py, c)
+}
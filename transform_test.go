@@ -0,0 +1,77 @@
+package roboeyestinygo
+
+import "testing"
+
+// renderTransformed draws a settled angry frame looking north-east
+func renderTransformed(dev DeviceInterface, rotation Rotation, mirrorX, mirrorY bool) *RoboEyes {
+	r := newTestEyes(dev, 50)
+	r.SetRotation(rotation)
+	r.SetMirror(mirrorX, mirrorY)
+	r.SetAntialiasing(true)
+	r.Open()
+	r.SetMood(MoodAngry)
+	r.SetDirection(DirNE)
+	step(r, 40)
+	return r
+}
+
+func TestRotationMatchesReference(t *testing.T) {
+	ref := newTestDevice(128, 64)
+	renderTransformed(ref, Rotate0, false, false)
+	for _, tc := range []struct {
+		rotation         Rotation
+		mirrorX, mirrorY bool
+	}{
+		{Rotate90, false, false}, {Rotate180, false, false}, {Rotate270, false, false},
+		{Rotate0, true, false}, {Rotate0, false, true}, {Rotate90, true, true}, {Rotate270, false, true},
+	} {
+		width, height := int16(128), int16(64)
+		if tc.rotation == Rotate90 || tc.rotation == Rotate270 {
+			width, height = height, width
+		}
+		plain, fast := newTestDevice(width, height), newFastDevice(width, height)
+		for _, dev := range []DeviceInterface{plain, fast} {
+			r := renderTransformed(dev, tc.rotation, tc.mirrorX, tc.mirrorY)
+			if w, h := r.Size(); w != 128 || h != 64 {
+				t.Fatalf("%+v: screen %dx%d", tc, w, h)
+			}
+		}
+		r := &RoboEyes{}
+		r.Begin(plain, width, height, 50)
+		r.SetRotation(tc.rotation)
+		r.SetMirror(tc.mirrorX, tc.mirrorY)
+		bad := 0
+		for y := int16(0); y < 64; y++ {
+			for x := int16(0); x < 128; x++ {
+				px, py := r.toPanel(x, y)
+				if plain.at(px, py) != ref.at(x, y) || fast.at(px, py) != ref.at(x, y) {
+					bad++
+				}
+			}
+		}
+		if bad != 0 {
+			t.Errorf("%+v: %d pixels differ from the unrotated frame", tc, bad)
+		}
+	}
+}
+
+func TestToPanelRect(t *testing.T) {
+	r := newTestEyes(newTestDevice(64, 128), 50)
+	for _, rotation := range []Rotation{Rotate0, Rotate90, Rotate180, Rotate270} {
+		r.SetRotation(rotation)
+		r.SetMirror(rotation == Rotate90, rotation == Rotate180)
+		x, y, w, h := r.toPanelRect(3, 5, 10, 20)
+		// Every pixel of the screen rectangle lands inside the panel rectangle
+		for j := int16(5); j < 25; j++ {
+			for i := int16(3); i < 13; i++ {
+				px, py := r.toPanel(i, j)
+				if px < x || px >= x+w || py < y || py >= y+h {
+					t.Fatalf("rotation %d: %d,%d maps to %d,%d outside %d,%d %dx%d", rotation, i, j, px, py, x, y, w, h)
+				}
+			}
+		}
+		if int(w)*int(h) != 200 {
+			t.Errorf("rotation %d: %dx%d", rotation, w, h)
+		}
+	}
+}
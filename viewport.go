@@ -0,0 +1,28 @@
+package roboeyestinygo
+
+// SetViewport restricts the eyes to the rectangle at (x, y) of the given
+// size on the display, after rotation. Only that rectangle is cleared and
+// drawn, so status icons or text can share the framebuffer around it.
+// The eyes are laid out again in the viewport, a width or height of 0
+// gives them the whole display back.
+func (r *RoboEyes) SetViewport(x, y, width, height int16) {
+	r.viewport = region{x, y, width, height}
+	r.applyTransform()
+}
+
+// clear erases the previous frame, the whole buffer when the eyes own the
+// display and only the viewport otherwise. A background picture is painted
+// over the viewport instead.
+func (r *RoboEyes) clear() {
+	if r.background != nil {
+		r.backdrop = true
+		r.fillRect(0, 0, r.screenWidth, r.screenHeight, r.bgColor)
+		r.backdrop = false
+		return
+	}
+	if r.fullScreen {
+		r.device.ClearBuffer()
+		return
+	}
+	r.fillRect(0, 0, r.screenWidth, r.screenHeight, r.bgColor)
+}
@@ -0,0 +1,86 @@
+package roboeyestinygo
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestViewport(t *testing.T) {
+	marker := color.RGBA{1, 2, 3, 255}
+	ref := newTestDevice(80, 40)
+	renderMood(ref, MoodHappy, func(r *RoboEyes) { r.SetAutoScale(true); settle(r) })
+	for _, rotation := range []Rotation{Rotate0, Rotate90} {
+		width, height := int16(128), int16(64)
+		if rotation == Rotate90 {
+			width, height = height, width
+		}
+		d := newFastDevice(width, height)
+		for i := range d.pix {
+			d.pix[i] = marker
+		}
+		r := renderMood(d, MoodHappy, func(r *RoboEyes) {
+			r.SetRotation(rotation)
+			r.SetViewport(40, 20, 80, 40)
+			r.SetAutoScale(true)
+			settle(r)
+		})
+		if w, h := r.Size(); w != 80 || h != 40 {
+			t.Fatalf("rotation %d: screen %dx%d", rotation, w, h)
+		}
+		inside, outside := 0, 0
+		for y := int16(0); y < 64; y++ {
+			for x := int16(0); x < 128; x++ {
+				px, py := x, y
+				if rotation == Rotate90 {
+					px, py = 63-y, x
+				}
+				got := d.at(px, py)
+				if x >= 40 && x < 120 && y >= 20 && y < 60 {
+					if want := ref.at(x-40, y-20); got.R != want.R || got.G != want.G || got.B != want.B {
+						inside++
+					}
+				} else if got != marker {
+					outside++
+				}
+			}
+		}
+		if inside != 0 || outside != 0 {
+			t.Errorf("rotation %d: %d pixels differ in the viewport, %d drawn outside", rotation, inside, outside)
+		}
+	}
+}
+
+func TestDirtyRegionIsInPanelCoordinates(t *testing.T) {
+	d := newFastDevice(64, 128)
+	r := newTestEyes(d, 50)
+	r.SetRotation(Rotate90)
+	r.SetMirror(true, false)
+	r.SetViewport(4, 2, 100, 50)
+	r.Open()
+	step(r, 40)
+	prev := append([]color.RGBA(nil), d.pix...)
+	for _, dir := range []Direction{DirE, DirSW, DirN} {
+		r.SetDirection(dir)
+		for i := 0; i < 10; i++ {
+			step(r, 1)
+			x, y, w, h := r.DirtyRegion()
+			if x < 0 || y < 0 || x+w > d.width || y+h > d.height {
+				t.Fatalf("region %d,%d %dx%d outside the panel", x, y, w, h)
+			}
+			if w > 0 {
+				if got := d.regions[len(d.regions)-1]; got != [4]int16{x, y, w, h} {
+					t.Fatalf("sent %v, dirty region %d,%d %dx%d", got, x, y, w, h)
+				}
+			}
+			for py := int16(0); py < d.height; py++ {
+				for px := int16(0); px < d.width; px++ {
+					k := int(py)*int(d.width) + int(px)
+					if d.pix[k] != prev[k] && (px < x || px >= x+w || py < y || py >= y+h) {
+						t.Fatalf("pixel %d,%d changed outside %d,%d %dx%d", px, py, x, y, w, h)
+					}
+				}
+			}
+			copy(prev, d.pix)
+		}
+	}
+}